@@ -0,0 +1,78 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"menteslibres.net/gosexy/cli"
+	"sort"
+)
+
+var flagOutput = flag.String("o", "./export", "Directory the export command writes sites to.")
+
+func init() {
+	cli.Register("export", cli.Entry{
+		Name:        "export",
+		Description: "Writes every host's pages as static files.",
+		Arguments:   []string{"c", "o"},
+		Command:     &exportCommand{},
+	})
+}
+
+type exportCommand struct {
+}
+
+func (self *exportCommand) Execute() error {
+
+	var err error
+
+	if *flagSettings == "" {
+		*flagSettings = DEFAULT_SETTINGS_FILE
+	}
+
+	settings, err = loadSettings(*flagSettings)
+
+	if err != nil {
+		return fmt.Errorf("Error while reading settings file %s: %s", *flagSettings, err.Error())
+	}
+
+	names := []string{}
+	for name, _ := range hosts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		dir := *flagOutput + "/" + name
+
+		if err := hosts[name].Export(dir); err != nil {
+			return err
+		}
+
+		fmt.Printf("Exported %s to %s.\n", name, dir)
+	}
+
+	return nil
+}
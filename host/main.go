@@ -24,10 +24,12 @@
 package host
 
 import (
+	"bytes"
+	"crypto/sha1"
 	"fmt"
 	//"github.com/howeyc/fsnotify"
-	md "github.com/russross/blackfriday"
 	"html/template"
+	"io/ioutil"
 	"log"
 	"menteslibres.net/gosexy/to"
 	"menteslibres.net/gosexy/yaml"
@@ -37,7 +39,9 @@ import (
 	"os"
 	"path"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -50,6 +54,12 @@ var (
 	isExternalLinkPattern = regexp.MustCompile(`^[a-zA-Z0-9]+:\/\/`)
 )
 
+// Template functions pages can ask for with a "funcs" key on their front
+// matter, by set name. Programs embedding luminos register theirs here, the
+// "funcsets" document setting picks the ones a host hands its pages (all of
+// them if it's not set).
+var FuncSets = map[string]template.FuncMap{}
+
 // Virtual host that serves document of a given directory.
 type Host struct {
 	// Host name
@@ -75,16 +85,23 @@ type Host struct {
 	Watcher *watcher.Watcher
 	// Template root
 	TemplateRoot string
-}
-
-var extensions = []string{
-	".md",
-	".html",
-	".txt",
+	// Page builder for the documents under the webroot.
+	Builder *page.Builder
+	// Stops watching the webroot for changes.
+	stopContentWatch func()
+	// Checksums of the loaded templates, by name.
+	templateSums map[string]string
+	// Rules of the webroot's _redirects file, replaced by the content watcher
+	// while requests are being served.
+	redirects []page.Redirect
+	mu        sync.Mutex
 }
 
 func (self *Host) Close() {
 	self.Watcher.Close()
+	if self.stopContentWatch != nil {
+		self.stopContentWatch()
+	}
 }
 
 // Returns a relative URL.
@@ -153,127 +170,31 @@ func (host *Host) link(url, text string) template.HTML {
 	return template.HTML(fmt.Sprintf(`<a href="%s">%s</a>`, host.asset(url), text))
 }
 
-// Checks for files that could be transformed into the requested file
-
-const (
-	NO_TRANSFORM = iota
-	MARKDOWN_TRANSFORM = iota
-	REDIRECT_TRANSFORM = iota
-)
-
-func guessFileTransform(file string) (string, int) {
-	if strings.HasSuffix(file, "/") {
-		fmt.Printf("Trailing slash... [%s]\n", file)
-		// They specified the trailing '/'
-		actualpath := file + "/index.md"
-		_, err := os.Stat(actualpath)
-		if err == nil {
-			fmt.Printf(" it's a hit... [%s]\n", actualpath)
-			return actualpath, MARKDOWN_TRANSFORM
-		}
-		return file, NO_TRANSFORM
-	}
-	// no trailing "/" in the request
-	stat, err := os.Stat(file)
-	if err == nil {
-		fmt.Printf("No trailing slash... [%s]\n", file)
-		if stat.IsDir() {
-			// check to see if there is an index there,
-			// if so we want to redirect them to the proper "/"
-			// (note that the main reason we don't
-			// want to denote directories without the trailing
-			// slash is the interpretation of relative references
-			// within the directory's index.md, like an image ref)
-			actualpath := file + "/index.md"
-			_, err = os.Stat(actualpath)
-			if err == nil {
-				return file + "/", REDIRECT_TRANSFORM
-			}
-			// well, the name exists and it is a directory,
-			// but there is no index.md in it... we don't
-			// support "native" index listing, so tough luck
-			return file, NO_TRANSFORM
-		}
-	}
-	
-	actualpath := file + ".md"
-	_, err = os.Stat(actualpath)
-	if err == nil {
-		return actualpath, MARKDOWN_TRANSFORM
-	}
-	return actualpath, NO_TRANSFORM
-}
-
-
-// Checks for files names and returns a guessed name.
-func guessFile(file string, descend bool) (string, os.FileInfo) {
-	stat, err := os.Stat(file)
-
-	file = strings.TrimRight(file, PS)
-
-	if descend == true {
-		if err == nil {
-			if stat.IsDir() {
-				f, s := guessFile(file+PS+"index", true)
-				if s != nil {
-					return f, s
-				}
-			}
-			return file, stat
-		} else {
-			for _, extension := range extensions {
-				f, s := guessFile(file+extension, false)
-				if s != nil {
-					return f, s
-				}
-			}
-		}
-	} else {
-		if err == nil {
-			return file, stat
-		}
+func chunk(value string) string {
+	if value == "" {
+		return "-"
 	}
-
-	return "", nil
+	return value
 }
 
-// Reads a file, if the file has the .md extension the contents are parsed and HTML is returned.
-func (host *Host) readFile(file string) ([]byte, error) {
-	stat, err := os.Stat(file)
-
-	if err != nil {
-		return nil, err
+// Sends the page's ETag and, if the client already has that version of the
+// page, a 304 response. Returns true if the page does not need to be sent.
+func (host *Host) notModified(w http.ResponseWriter, req *http.Request, p *page.Page) bool {
+	if p.ETag == "" {
+		return false
 	}
 
-	if stat.IsDir() == false {
-
-		fp, _ := os.Open(file)
-		defer fp.Close()
-
-		buf := make([]byte, stat.Size())
-
-		_, err := fp.Read(buf)
-
-		if err != nil {
-			return nil, err
-		}
+	w.Header().Set("ETag", p.ETag)
 
-		if strings.HasSuffix(file, ".md") {
-			return md.MarkdownCommon(buf), nil
-		} else {
-			return buf, nil
+	for _, tag := range strings.Split(req.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "W/"))
+		if tag == p.ETag || tag == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
 		}
-
 	}
 
-	return nil, nil
-}
-
-func chunk(value string) string {
-	if value == "" {
-		return "-"
-	}
-	return value
+	return false
 }
 
 // A simple ServeHTTP.
@@ -287,6 +208,12 @@ func (host *Host) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	// "default" hostname). A per-request context would be useful.
 	host.Request = req
 
+	if target, ok := host.Builder.CanonicalRedirect(req.Host, req.URL.RequestURI()); ok {
+		// Sites served from a single host name.
+		http.Redirect(w, req, target, http.StatusMovedPermanently)
+		return
+	}
+
 	// Default status.
 	status := http.StatusNotFound
 	size := -1
@@ -302,104 +229,163 @@ func (host *Host) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	// Trying to match a file on webroot/
-	webrootdir := to.String(host.Settings.Get("document", "webroot"))
+	webroot := host.webroot()
 
-	if webrootdir == "" {
-		webrootdir = "webroot"
-	}
+	localFile = webroot + PS + reqpath
 
-	webroot := host.DocumentRoot + PS + webrootdir
+	if host.Builder.Overlay != "" {
+		// Files on the overlay shadow those on webroot/, its pages and
+		// directories are resolved by the builder.
+		if stat, err := os.Stat(host.Builder.Overlay + PS + reqpath); err == nil && stat.IsDir() == false {
+			localFile = host.Builder.Overlay + PS + reqpath
+		}
+	}
 
-	localFile = webroot + PS + reqpath
+	if mounted := host.Builder.MountedFile(localFile); mounted != localFile {
+		// So do files on the mounted roots.
+		if stat, err := os.Stat(mounted); err == nil && stat.IsDir() == false {
+			localFile = mounted
+		}
+	}
 
 	stat, err := os.Stat(localFile)
 
 	if err == nil {
 		// File exists
-		if stat.IsDir() == false {
-			// Exists and it's not a directory, let's serve it.
+		if stat.IsDir() == false && host.Builder.Servable(localFile) {
+			// Exists, it's not a directory nor an unpublished page, let's
+			// serve it.
+			status = http.StatusOK
+			w.Header().Set("Content-Type", host.Builder.ContentType(localFile, true))
+			if src, kept := host.Builder.KeptSource(localFile); kept {
+				// Without the front matter that made it keep its extension.
+				w.Write(src)
+				size = len(src)
+			} else {
+				http.ServeFile(w, req, localFile)
+				size = int(stat.Size())
+			}
+		}
+	}
+
+	if status == http.StatusNotFound && reqpath == "/sitemap.xml" {
+		// Unless there's a sitemap.xml file on the webroot, one is built.
+		scheme := "http"
+		if req.TLS != nil {
+			scheme = "https"
+		}
+
+		sitemap, err := host.Builder.BuildSitemap(host.Builder.Root, scheme+"://"+req.Host)
+
+		if err == nil {
+			status = http.StatusOK
+			size = len(sitemap)
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.Write(sitemap)
+		} else {
+			status = http.StatusInternalServerError
+			http.Error(w, err.Error(), status)
+		}
+	}
+
+	if status == http.StatusNotFound && path.Base(reqpath) == "feed.json" {
+		// A JSON Feed of the directory's dated pages, unless there's a
+		// feed.json file there.
+		scheme := "http"
+		if req.TLS != nil {
+			scheme = "https"
+		}
+
+		feed, err := host.Builder.BuildJSONFeed(path.Dir(reqpath), scheme+"://"+req.Host)
+
+		if err == nil {
 			status = http.StatusOK
-			http.ServeFile(w, req, localFile)
-			size = int(stat.Size())
+			size = len(feed)
+			w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+			w.Write(feed)
+		}
+	}
+
+	if status == http.StatusNotFound && path.Base(reqpath) == "_print" {
+		// All the pages of a directory, on a single printable page.
+		p, err := host.Builder.BuildPrintView(path.Dir(reqpath))
+
+		if err == nil {
+			var buf bytes.Buffer
+			if err = p.Execute(&buf, host.Templates["index.tpl"]); err == nil {
+				status = http.StatusOK
+				size = buf.Len()
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				w.Write(buf.Bytes())
+			}
 		}
 	}
 
 	if status == http.StatusNotFound {
 		// Check for a corresponding .md file
 
-		localFile, transform := guessFileTransform(localFile)
+		localFile, transform := host.Builder.Resolve(localFile)
 
 		switch transform {
-		case NO_TRANSFORM:
+		case page.NO_TRANSFORM:
 			break
 
-		case REDIRECT_TRANSFORM:
+		case page.REDIRECT_TRANSFORM:
 			http.Redirect(w, req, "/"+host.Path+"/"+reqpath+"/", 301)
 			w.Write([]byte(http.StatusText(301)))
 			return
 			
-		case MARKDOWN_TRANSFORM:
-			fmt.Printf("reqpath = [%s] local = [%s]\n", 
-				reqpath,
-				localFile);
-			
-			p := &page.Page{}
-
-			p.FilePath = localFile
-			p.BasePath = req.URL.Path
-
-			relPath := localFile[len(webroot):]
-			fmt.Printf("   relPath = [%s]\n", relPath)
-			
-			p.FileDir = path.Dir(localFile)
-			p.BasePath = path.Dir(relPath)
-
-			content, err := host.readFile(localFile)
-
+		case page.MOVED_TRANSFORM, page.DEFAULT_TRANSFORM:
+			// The page is served at its slug, or it's the default page
+			// of the requested directory; the latter may change, so
+			// browsers must not keep it.
+			target, err := host.Builder.URLByPath(localFile[len(host.Builder.Root):])
 			if err == nil {
-				p.Content = template.HTML(content)
+				code := http.StatusMovedPermanently
+				if transform == page.DEFAULT_TRANSFORM {
+					code = http.StatusFound
+				}
+				http.Redirect(w, req, host.asset(target), code)
+				return
 			}
 
-			p.FileDir = strings.TrimRight(p.FileDir, PS) + PS
-			p.BasePath = strings.TrimRight(p.BasePath, PS) + PS
-
-			// werc-like header and footer.
-			hfile, hstat := guessFile(p.FileDir+"_header", true)
+		case page.LISTING_TRANSFORM:
+			p, err := host.Builder.BuildDirectoryIndex(reqpath)
 
-			if hstat != nil {
-				hcontent, herr := host.readFile(hfile)
-				if herr == nil {
-					p.ContentHeader = template.HTML(hcontent)
-				}
+			if err == nil {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				err = p.Execute(w, host.Templates["index.tpl"])
 			}
 
-			if p.BasePath == "/" {
-				p.IsHome = true
+			if err == nil {
+				status = http.StatusOK
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				status = http.StatusInternalServerError
 			}
 
-			// werc-like header and footer.
-			ffile, fstat := guessFile(p.FileDir+"_footer", true)
+		case page.RAW_TRANSFORM:
+			// A file of an extension without a renderer.
+			status = http.StatusOK
+			w.Header().Set("Content-Type", host.Builder.ContentType(localFile, true))
+			http.ServeFile(w, req, host.Builder.RawFile(localFile))
 
-			if fstat != nil {
-				fcontent, ferr := host.readFile(ffile)
-				if ferr == nil {
-					p.ContentFooter = template.HTML(fcontent)
-				}
-			}
+		case page.MARKDOWN_TRANSFORM:
+			fmt.Printf("reqpath = [%s] local = [%s]\n", 
+				reqpath,
+				localFile);
+			
+			p, err := host.Builder.Build(localFile)
 
-			if p.Content != "" {
-				title, _ := regexp.Compile(`<h[\d]>(.+)</h`)
-				found := title.FindStringSubmatch(string(p.Content))
-				if len(found) > 0 {
-					p.Title = found[1]
-				}
+			if err == nil && host.notModified(w, req, p) {
+				status = http.StatusNotModified
+				break
 			}
 
-			p.CreateBreadCrumb()
-			p.CreateMenu()
-			p.CreateSideMenu()
-
-			err = host.Templates["index.tpl"].Execute(w, p)
+			if err == nil {
+				w.Header().Set("Content-Type", host.Builder.ContentType(localFile, false))
+				err = p.Execute(w, host.Templates["index.tpl"])
+			}
 
 			if err == nil {
 				status = http.StatusOK
@@ -412,7 +398,31 @@ func (host *Host) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if status == http.StatusNotFound {
-		http.Error(w, "Not found", http.StatusNotFound)
+		for _, rule := range host.redirectRules() {
+			if target, ok := rule.Target(reqpath); ok {
+				status = rule.Status
+				http.Redirect(w, req, host.asset(target), rule.Status)
+				break
+			}
+		}
+	}
+
+	if status == http.StatusNotFound {
+		// Rendering the error within the site's layout, so the visitor still
+		// has a menu to go on from.
+		p := host.Builder.BuildNotFound(reqpath)
+
+		var buf bytes.Buffer
+
+		err := p.Execute(&buf, host.Templates["index.tpl"])
+
+		if err == nil {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write(buf.Bytes())
+		} else {
+			http.Error(w, "Not found", http.StatusNotFound)
+		}
 	}
 
 	fmt.Println(strings.Join([]string{
@@ -434,12 +444,34 @@ func (self *Host) loadTemplate(file string) error {
 
 	parsed := template.New(name)
 
+	// Functions of the FuncSets are replaced on execution for the pages that
+	// ask for them, the stubs only let the template parse.
+	if self.Builder != nil {
+		parsed = parsed.Funcs(self.Builder.FuncStubs())
+	}
+
 	parsed, err := parsed.Funcs(self.funcMap).ParseFiles(file)
 
 	if err != nil {
 		return err
 	}
 
+	buf, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		return err
+	}
+
+	if self.templateSums == nil {
+		self.templateSums = map[string]string{}
+	}
+
+	self.templateSums[name] = fmt.Sprintf("%x", sha1.Sum(buf))
+
+	if self.Builder != nil {
+		self.Builder.TemplateVersion = self.templateVersion()
+	}
+
 	self.Templates[name] = parsed
 
 	if self.Watcher != nil {
@@ -606,6 +638,244 @@ func (host *Host) fileWatcher() error {
 
 }
 
+// Returns the directory documents are served from.
+func (host *Host) webroot() string {
+	webrootdir := to.String(host.Settings.Get("document", "webroot"))
+
+	if webrootdir == "" {
+		webrootdir = "webroot"
+	}
+
+	return host.DocumentRoot + PS + webrootdir
+}
+
+// Writes the site's pages, rendered with its index.tpl, into dir.
+func (host *Host) Export(dir string) error {
+	tpl, ok := host.Templates["index.tpl"]
+
+	if ok == false {
+		return fmt.Errorf("Could not export %s: there is no index.tpl template.", host.Name)
+	}
+
+	return host.Builder.Export(dir, tpl)
+}
+
+// Returns a list of strings from the "document" settings of site.yaml.
+func (host *Host) DocumentStrings(name string) []string {
+	list := []string{}
+	for _, value := range to.List(host.Settings.Get("document", name)) {
+		list = append(list, to.String(value))
+	}
+	return list
+}
+
+// Reads the site's data files into the page builder, a broken data file is
+// logged and leaves the previous data in place.
+func (host *Host) loadData(builder *page.Builder) {
+	data, err := page.LoadData(builder.Root)
+
+	if err != nil {
+		log.Printf("%s: %s\n", host.Name, err.Error())
+		return
+	}
+
+	builder.SetData(data)
+}
+
+// Returns a checksum of every loaded template.
+func (host *Host) templateVersion() string {
+	names := []string{}
+	for name, _ := range host.templateSums {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	sums := []string{}
+	for _, name := range names {
+		sums = append(sums, name+":"+host.templateSums[name])
+	}
+
+	return strings.Join(sums, " ")
+}
+
+// Reads the webroot's _redirects file, a broken file is logged and leaves the
+// previous rules in place.
+func (host *Host) loadRedirects(builder *page.Builder) {
+	rules, err := page.ParseRedirects(builder.Root)
+
+	if err != nil {
+		log.Printf("%s: %s\n", host.Name, err.Error())
+		return
+	}
+
+	host.mu.Lock()
+	host.redirects = rules
+	host.mu.Unlock()
+}
+
+// Returns the current rules of the webroot's _redirects file.
+func (host *Host) redirectRules() []page.Redirect {
+	host.mu.Lock()
+	defer host.mu.Unlock()
+	return host.redirects
+}
+
+// Passes the "document" settings down to the page builder.
+func (host *Host) configureBuilder(builder *page.Builder) {
+
+	builder.TemplateVersion = host.templateVersion()
+
+	// Links are built under the route the host is mounted at.
+	builder.Prefix = host.Path
+
+	if index := host.DocumentStrings("index"); len(index) > 0 {
+		builder.IndexPrecedence = index
+	}
+
+	builder.IncludePrecedence = host.DocumentStrings("include_precedence")
+
+	if schema := to.Map(host.Settings.Get("document", "schema")); len(schema) > 0 {
+		builder.FrontMatterSchema = map[string]string{}
+		for key, kind := range schema {
+			builder.FrontMatterSchema[key] = to.String(kind)
+		}
+	}
+
+	if profiles := to.Map(host.Settings.Get("document", "profiles")); len(profiles) > 0 {
+		builder.FrontMatterProfiles = map[string]map[string]interface{}{}
+		for name, profile := range profiles {
+			builder.FrontMatterProfiles[name] = to.Map(profile)
+		}
+	}
+
+	builder.EmojiReplace = to.Bool(host.Settings.Get("document", "emoji"))
+	builder.Environment = to.String(host.Settings.Get("document", "environment"))
+	builder.OpenGraphType = to.String(host.Settings.Get("document", "og_type"))
+	builder.RemoveLead = to.Bool(host.Settings.Get("document", "remove_lead"))
+	builder.DefaultTitle = to.String(host.Settings.Get("document", "default_title"))
+	builder.DateFormat = to.String(host.Settings.Get("document", "date_format"))
+	builder.DateLocale = to.String(host.Settings.Get("document", "date_locale"))
+	builder.LinkStyle = to.String(host.Settings.Get("document", "link_style"))
+	builder.SlugRedirects = to.Bool(host.Settings.Get("document", "slug_redirects"))
+	builder.UnknownExtensions = to.String(host.Settings.Get("document", "unknown_extensions"))
+
+	// Renderers by extension (i.e: ".txt: pre").
+	if renderers := to.Map(host.Settings.Get("document", "renderers")); len(renderers) > 0 {
+		builder.Renderers = make(map[string]string)
+		for ext, renderer := range renderers {
+			builder.Renderers["."+strings.TrimLeft(ext, ".")] = to.String(renderer)
+		}
+	}
+
+	builder.TOCMarker = to.String(host.Settings.Get("document", "toc_marker"))
+	builder.DisableTOC = to.Bool(host.Settings.Get("document", "disable_toc"))
+	builder.AllowedMetaKeys = host.DocumentStrings("allowed_meta_keys")
+	builder.StripOrderPrefix = to.Bool(host.Settings.Get("document", "strip_order_prefix"))
+	builder.OrderPrefixPattern = to.String(host.Settings.Get("document", "order_prefix_pattern"))
+	builder.SectionTopMenu = to.Bool(host.Settings.Get("document", "section_top_menu"))
+	builder.PrevNextAcrossSections = to.Bool(host.Settings.Get("document", "prev_next_across_sections"))
+	builder.DebugSourceComments = to.Bool(host.Settings.Get("document", "debug_source_comments"))
+	builder.CompressOutput = to.Bool(host.Settings.Get("document", "compress_output"))
+	builder.StrictMode = to.Bool(host.Settings.Get("document", "strict"))
+	builder.RequiredFrontMatter = host.DocumentStrings("required")
+	builder.SlugCollisions = to.String(host.Settings.Get("document", "slug_collisions"))
+
+	// Template function sets (see FuncSets).
+	names := host.DocumentStrings("funcsets")
+	if len(names) == 0 {
+		for name, _ := range FuncSets {
+			names = append(names, name)
+		}
+	}
+	builder.FuncSets = map[string]template.FuncMap{}
+	for _, name := range names {
+		if set, ok := FuncSets[name]; ok {
+			builder.FuncSets[name] = set
+		} else {
+			log.Printf("%s: There are no template functions named %s.\n", host.Name, name)
+		}
+	}
+	builder.HomeDocument = to.String(host.Settings.Get("document", "home"))
+	builder.CodeLineNumbers = to.Bool(host.Settings.Get("document", "code_line_numbers"))
+	builder.MinTitleLevel = int(to.Int64(host.Settings.Get("document", "min_title_level")))
+	builder.MaxTitleLevel = int(to.Int64(host.Settings.Get("document", "max_title_level")))
+	builder.DiagramLanguages = host.DocumentStrings("diagrams")
+	builder.GitModTime = to.Bool(host.Settings.Get("document", "git_mod_time"))
+	builder.SourceEncoding = to.String(host.Settings.Get("document", "encoding"))
+	builder.Concurrency = int(to.Int64(host.Settings.Get("document", "concurrency")))
+	builder.MaxItemsPerLevel = int(to.Int64(host.Settings.Get("document", "max_items_per_level")))
+
+	// Markdown extensions, those not set keep their defaults.
+	markdown := map[string]*bool{
+		"tables":           &builder.Markdown.Tables,
+		"strikethrough":    &builder.Markdown.Strikethrough,
+		"task_lists":       &builder.Markdown.TaskLists,
+		"hard_line_breaks": &builder.Markdown.HardLineBreaks,
+		"autolink":         &builder.Markdown.Autolink,
+		"footnotes":        &builder.Markdown.Footnotes,
+		"smart_typography": &builder.Markdown.SmartTypography,
+		"definition_lists": &builder.Markdown.DefinitionLists,
+	}
+
+	for key, flag := range markdown {
+		if value := host.Settings.Get("document", "markdown", key); value != nil {
+			*flag = to.Bool(value)
+		}
+	}
+	if capitalize := host.Settings.Get("document", "capitalize_titles"); capitalize != nil {
+		builder.CapitalizeTitles = to.Bool(capitalize)
+	}
+
+	if sideMenu := host.Settings.Get("document", "index_side_menu"); sideMenu != nil {
+		builder.IndexShowsSideMenu = to.Bool(sideMenu)
+	}
+
+	builder.ShowDrafts = to.Bool(host.Settings.Get("document", "preview"))
+	builder.GroupRecursive = to.Bool(host.Settings.Get("document", "group_recursive"))
+	builder.MaxContentBytes = to.Int64(host.Settings.Get("document", "max_content_bytes"))
+	if autoIndex := host.Settings.Get("document", "auto_index"); autoIndex != nil {
+		builder.AutoIndex = to.Bool(autoIndex)
+	}
+	builder.AllowRemoteIncludes = host.DocumentStrings("remote_includes")
+	builder.CanonicalHost = to.String(host.Settings.Get("document", "canonical_host"))
+
+	for _, dir := range host.DocumentStrings("include_paths") {
+		if path.IsAbs(dir) == false {
+			dir = host.DocumentRoot + PS + dir
+		}
+		builder.IncludePaths = append(builder.IncludePaths, strings.TrimRight(dir, PS))
+	}
+
+	builder.SideMenuExcludeCurrent = to.Bool(host.Settings.Get("document", "side_menu_exclude_current"))
+	builder.CachePages = to.Bool(host.Settings.Get("document", "warm_cache"))
+
+	if overlay := to.String(host.Settings.Get("document", "overlay")); overlay != "" {
+		if path.IsAbs(overlay) == false {
+			overlay = host.DocumentRoot + PS + overlay
+		}
+		builder.Overlay = strings.TrimRight(overlay, PS)
+	}
+
+	if mounts := to.Map(host.Settings.Get("document", "mounts")); len(mounts) > 0 {
+		builder.Mounts = map[string]string{}
+		for prefix, dir := range mounts {
+			dir := to.String(dir)
+			if path.IsAbs(dir) == false {
+				dir = host.DocumentRoot + PS + dir
+			}
+			builder.Mounts[prefix] = strings.TrimRight(dir, PS)
+		}
+	}
+
+	if listing := to.String(host.Settings.Get("document", "listing")); listing != "" {
+		if path.IsAbs(listing) == false {
+			listing = host.DocumentRoot + PS + listing
+		}
+		builder.ListingTemplate = listing
+	}
+}
+
 // Loads host settings.
 func (host *Host) loadSettings() error {
 
@@ -631,6 +901,56 @@ func (host *Host) loadSettings() error {
 
 	host.Settings = settings
 
+	builder, err := page.NewBuilder(host.webroot())
+
+	if err != nil {
+		return err
+	}
+
+	// Content changes invalidate the builder's caches.
+	changes, stop, err := builder.Watch(builder.Root)
+
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for file := range changes {
+			log.Printf("%s: Content changed %s\n", host.Name, file)
+			if strings.Contains(file, PS+"_data"+PS) {
+				host.loadData(builder)
+			}
+			if path.Base(file) == "_redirects" {
+				host.loadRedirects(builder)
+			}
+		}
+	}()
+
+	if host.stopContentWatch != nil {
+		host.stopContentWatch()
+	}
+
+	host.configureBuilder(builder)
+	host.loadData(builder)
+	host.loadRedirects(builder)
+
+	// Rendering everything up front, so the first visitors don't wait.
+	if builder.CachePages {
+		if err := builder.WarmCache(builder.Root); err != nil {
+			log.Printf("%s: Could not warm the cache: %s\n", host.Name, err.Error())
+		}
+	}
+
+	host.Builder = builder
+	host.stopContentWatch = stop
+
+	// Templates are parsed with the functions of the builder's FuncSets.
+	if host.TemplateRoot != "" {
+		if err := host.loadTemplates(); err != nil {
+			log.Printf("%s: %s\n", host.Name, err.Error())
+		}
+	}
+
 	return nil
 }
 
@@ -666,6 +986,7 @@ func New(name string, root string) (*Host, error) {
 			"jstext":   jstext,
 			"htmltext": htmltext,
 			"link":     func(a, b string) template.HTML { return host.link(a, b) },
+			"urlfor":   func(s string) (string, error) { return host.Builder.URLByPath(s) },
 		}
 
 		// Watcher
@@ -696,6 +1017,4 @@ func New(name string, root string) (*Host, error) {
 		log.Printf("Checkout an example directory at https://github.com/xiam/luminos/tree/master/default\n")
 		return nil, err
 	}
-
-	return nil, nil
 }
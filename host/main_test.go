@@ -0,0 +1,64 @@
+package host
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dkolbly/luminos/page"
+	"menteslibres.net/gosexy/yaml"
+)
+
+func TestPrefixedHost(t *testing.T) {
+	root, err := ioutil.TempDir("", "luminos-host")
+	if err != nil {
+		t.Fatalf("Could not create a temporary directory: %s", err.Error())
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string]string{
+		settingsFile:                    "document:\n  home: index.md\n",
+		"webroot/index.md":              "# Home\n",
+		"webroot/guide/index.md":        "# Guide\n",
+		"webroot/guide/installation.md": "# Installation\n",
+	}
+
+	for name, content := range files {
+		file := filepath.Join(root, name)
+		os.MkdirAll(filepath.Dir(file), os.ModeDir|0755)
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Could not write %s: %s", file, err.Error())
+		}
+	}
+
+	settings, err := yaml.Open(filepath.Join(root, settingsFile))
+	if err != nil {
+		t.Fatalf("Could not open settings: %s", err.Error())
+	}
+
+	host := &Host{Name: "example.org/docs", Path: "docs", DocumentRoot: root, Settings: settings}
+
+	builder, err := page.NewBuilder(filepath.Join(root, "webroot"))
+	if err != nil {
+		t.Fatalf("Could not create a builder: %s", err.Error())
+	}
+
+	host.configureBuilder(builder)
+
+	tests := map[string]string{
+		"":                      "/docs/",
+		"guide":                 "/docs/guide/",
+		"guide/installation.md": "/docs/guide/installation",
+	}
+
+	for rel, expected := range tests {
+		url, err := builder.URLByPath(rel)
+		if err != nil {
+			t.Fatalf("URLByPath(%q): %s", rel, err.Error())
+		}
+		if url != expected {
+			t.Fatalf("Expected URLByPath(%q) to be %s, got %s.", rel, expected, url)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// Name of the file that declares who may read a directory.
+const accessFile = "_access.yaml"
+
+// Returns the role (from the "role" or "group" key of the nearest _access.yaml
+// file) required to read the given URL path, if any. Luminos does not
+// authenticate anyone, this is meant to be enforced in front of it.
+func (b *Builder) AccessPolicy(urlPath string) (string, bool) {
+	chunks := strings.Split(strings.Trim(path.Clean("/"+urlPath), "/"), "/")
+
+	for i := len(chunks); i >= 0; i-- {
+		dir := b.Root + PS + strings.Join(chunks[:i], PS)
+
+		buf, err := ioutil.ReadFile(strings.TrimRight(dir, PS) + PS + accessFile)
+
+		if err != nil {
+			continue
+		}
+
+		policy := map[string]interface{}{}
+
+		if err = yaml.Unmarshal(buf, &policy); err != nil {
+			Logger.Printf("Could not parse %s: %s\n", dir+PS+accessFile, err.Error())
+			continue
+		}
+
+		for _, key := range []string{"role", "group"} {
+			if role := metaString(policy, key); role != "" {
+				return role, true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,28 @@
+package page
+
+import (
+	"testing"
+)
+
+func TestAccessPolicy(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":               "# Home",
+		"members/_access.yaml":   "role: member\n",
+		"members/index.md":       "# Members",
+		"members/deep/secret.md": "# Secret",
+		"public/page.md":         "# Public",
+	})
+
+	for _, url := range []string{"/members/", "/members/deep/secret", "members/deep/"} {
+		role, ok := b.AccessPolicy(url)
+		if ok == false || role != "member" {
+			t.Fatalf("%s: expecting the inherited member role, got %q", url, role)
+		}
+	}
+
+	for _, url := range []string{"/", "/public/page"} {
+		if role, ok := b.AccessPolicy(url); ok {
+			t.Fatalf("%s: expecting no policy, got %q", url, role)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// Returns the HTML of a crumb's text, menu titles are HTML already.
+func crumbText(text interface{}) string {
+	if html, ok := text.(template.HTML); ok {
+		return string(html)
+	}
+	return template.HTMLEscapeString(fmt.Sprint(text))
+}
+
+// Renders BreadCrumb as a <nav> with an <ol> of links, separated by sep, that
+// ends with the current page's title as plain text.
+func (p *Page) BreadCrumbHTML(sep string) template.HTML {
+	items := []string{}
+
+	current := template.HTMLEscapeString(p.Title)
+
+	for i, crumb := range p.BreadCrumb {
+		last := i == len(p.BreadCrumb)-1
+		if last && (crumb["current"] == true || crumb["link"] == p.Link) {
+			// The page is a directory index, or the home page.
+			current = crumbText(crumb["text"])
+			break
+		}
+		items = append(items, fmt.Sprintf(`<li><a href="%s">%s</a></li>`, template.HTMLEscapeString(fmt.Sprint(crumb["link"])), crumbText(crumb["text"])))
+	}
+
+	items = append(items, fmt.Sprintf(`<li aria-current="page">%s</li>`, current))
+
+	separator := fmt.Sprintf(`<li aria-hidden="true">%s</li>`, template.HTMLEscapeString(sep))
+
+	return template.HTML(`<nav class="breadcrumb"><ol>` + strings.Join(items, separator) + `</ol></nav>`)
+}
+
+// A crumb of BreadCrumb, as returned by BreadCrumbPairs.
+type BreadCrumbPair struct {
+	Text    string
+	Link    string
+	Current bool
+}
+
+// Returns BreadCrumb as typed pairs, for callers that would rather not deal
+// with maps. Crumbs without a link (the home page's) have an empty Link.
+func (p *Page) BreadCrumbPairs() []BreadCrumbPair {
+	pairs := make([]BreadCrumbPair, 0, len(p.BreadCrumb))
+
+	for _, crumb := range p.BreadCrumb {
+		pair := BreadCrumbPair{
+			Current: crumb["current"] == true,
+		}
+		if text, ok := crumb["text"]; ok && text != nil {
+			pair.Text = fmt.Sprint(text)
+		}
+		if link, ok := crumb["link"]; ok && link != nil {
+			pair.Link = fmt.Sprint(link)
+		}
+		pairs = append(pairs, pair)
+	}
+
+	return pairs
+}
@@ -0,0 +1,78 @@
+package page
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBreadCrumbHTML(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":              "# Home",
+		"guide/_section.yaml":   "title: Guide & Tips\n",
+		"guide/basics/index.md": "# Basics",
+		"guide/basics/first.md": "---\ntitle: First <steps> & more\n---\nText.",
+	})
+
+	p, err := b.Build(b.Root + PS + "guide/basics/first.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<nav class="breadcrumb"><ol>` +
+		`<li><a href="/">Home</a></li><li aria-hidden="true">&gt;</li>` +
+		`<li><a href="/guide/">Guide &amp; Tips</a></li><li aria-hidden="true">&gt;</li>` +
+		`<li><a href="/guide/basics/">Basics</a></li><li aria-hidden="true">&gt;</li>` +
+		`<li aria-current="page">First &lt;steps&gt; &amp; more</li>` +
+		`</ol></nav>`
+
+	if html := string(p.BreadCrumbHTML(">")); html != expected {
+		t.Fatalf("Expecting %s, got %s", expected, html)
+	}
+
+	p, err = b.Build(b.Root + PS + "guide/basics/index.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `<nav class="breadcrumb"><ol>` +
+		`<li><a href="/">Home</a></li><li aria-hidden="true">/</li>` +
+		`<li><a href="/guide/">Guide &amp; Tips</a></li><li aria-hidden="true">/</li>` +
+		`<li aria-current="page">Basics</li>` +
+		`</ol></nav>`
+
+	if html := string(p.BreadCrumbHTML("/")); html != expected {
+		t.Fatalf("Expecting the index's own crumb not to be a link, got %s", html)
+	}
+}
+
+func TestBreadCrumbPairs(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":              "# Home",
+		"guide/basics/index.md": "# Basics",
+		"guide/basics/first.md": "# First",
+	})
+
+	for _, file := range []string{"guide/basics/first.md", "index.md"} {
+		p, err := b.Build(b.Root + PS + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pairs := p.BreadCrumbPairs()
+
+		if file == "guide/basics/first.md" && (len(pairs) != 3 || pairs[2] != BreadCrumbPair{Text: "Basics", Link: "/guide/basics/"}) {
+			t.Fatalf("Expecting three crumbs ending at /guide/basics/, got %+v", pairs)
+		}
+
+		if len(pairs) != len(p.BreadCrumb) {
+			t.Fatalf("Expecting %d pairs, got %d", len(p.BreadCrumb), len(pairs))
+		}
+
+		for i, crumb := range p.BreadCrumb {
+			link, _ := crumb["link"].(string)
+			if pairs[i].Text != fmt.Sprint(crumb["text"]) || pairs[i].Link != link || pairs[i].Current != (crumb["current"] == true) {
+				t.Fatalf("Expecting pair %d to match %v, got %+v", i, crumb, pairs[i])
+			}
+		}
+	}
+}
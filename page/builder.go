@@ -0,0 +1,636 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Builder knows where the content of a site lives and how its URLs are
+// formed, it holds the settings shared by every page of the site.
+type Builder struct {
+	// Content root directory (the webroot).
+	Root string
+
+	// Path the content is mounted under, without leading or trailing slashes
+	// (i.e: "docs" for a host routed at example.org/docs).
+	Prefix string
+
+	// Index file names tried, in order, when a directory is requested.
+	IndexPrecedence []string
+
+	// Markdown extensions content is rendered with.
+	Markdown MarkdownOptions
+
+	// Converter of .rst pages, BasicRST if nil. Beware that BasicRST is only a
+	// stub: .rst pages are served and listed on menus just like markdown
+	// ones, but only their titles and paragraphs are converted unless a
+	// complete converter is plugged in here.
+	RST RSTRenderer
+
+	// Marker (i.e: "[[TOC]]") replaced with the table of contents of the
+	// page it's on, see Page.postRender.
+	TOCMarker string
+
+	// Whether the TOCMarker is removed instead.
+	DisableTOC bool
+
+	// Extensions tried, in order, for _header and _footer files (".md",
+	// ".html" then ".txt" if empty). Markdown is rendered, anything else is
+	// included as it is.
+	IncludePrecedence []string
+
+	// Expected types of front matter keys (key => "string", "int", "bool",
+	// "date" or "list"), checked by ValidateFrontMatter.
+	FrontMatterSchema map[string]string
+
+	// Front matter keys every page must have, checked in StrictMode.
+	RequiredFrontMatter []string
+
+	// Front matter keys left on the Meta (and FrontMatter) of built pages,
+	// all of them if empty. Others are still used while building and by the
+	// page's own methods (i.e: Canonical or Visibility).
+	AllowedMetaKeys []string
+
+	// Whether Build fails, with Problems, on pages that have dead links,
+	// front matter that doesn't validate, a slug taken by another page or
+	// no way to be reached from the menus.
+	StrictMode bool
+
+	// Template functions, by the name of their set, pages may ask for with a
+	// "funcs" key on their front matter (see Page.Funcs and FuncStubs).
+	FuncSets map[string]template.FuncMap
+
+	// Front matter blocks, by name, pages may extend with an "_extends" key.
+	FrontMatterProfiles map[string]map[string]interface{}
+
+	// Preview mode: list and serve drafts and pages dated in the future.
+	ShowDrafts bool
+
+	// Clock used to tell whether a page is due, time.Now if nil.
+	Now func() time.Time
+
+	// Go time layout of the "date_formatted" value of menu and listing items
+	// (i.e: "2006-01-02"), "January 2, 2006" if empty.
+	DateFormat string
+
+	// Language the names of months and weekdays of "date_formatted" values
+	// are written in (i.e: "es" or "pt_BR"), one of "de", "es", "fr", "it"
+	// or "pt". English if empty or unknown.
+	DateLocale string
+
+	// Title of pages that have no title in their front matter, no headings and
+	// an empty or purely numeric file name.
+	DefaultTitle string
+
+	// Value of the og:type tag written by Page.OpenGraph, "website" if empty.
+	OpenGraphType string
+
+	// Remove the lead paragraph from the content it's extracted from.
+	RemoveLead bool
+
+	// Replace :shortcode: tokens with emoji on content and titles.
+	EmojiReplace bool
+
+	// Name of the environment the site is served in (i.e: "staging"), blocks
+	// between {{% env "staging" %}} and {{% /env %}} are only rendered there.
+	Environment string
+
+	// Largest content file, in bytes, that is read and rendered, larger files
+	// are refused. No limit if 0.
+	MaxContentBytes int64
+
+	// List the contents of directories that have no index file (the
+	// default), they're not found otherwise.
+	AutoIndex bool
+
+	// Template used for directory listings that have no _listing.html file of
+	// their own, a built-in one is used if empty.
+	ListingTemplate string
+
+	// List every page below a section on BuildGroupedIndex, not only those
+	// directly within it.
+	GroupRecursive bool
+
+	// Identifies the templates pages are rendered with, part of every
+	// Page.ETag so they change when the templates do.
+	TemplateVersion string
+
+	// Leave the current page out of its side menu, instead of marking its
+	// entry as "active".
+	SideMenuExcludeCurrent bool
+
+	// Hosts {{ include "..." }} directives may fetch content from, remote
+	// includes are refused if empty.
+	AllowRemoteIncludes []string
+
+	// Host name the site is served at (i.e: "example.org", or
+	// "https://example.org" for redirections to use https), requests for
+	// other hosts are redirected there. Any host is served if it's empty.
+	CanonicalHost string
+
+	// Directories outside of the content root (i.e: shared partials) that
+	// {{ include "..." }} directives look into when the file is not next to
+	// the page. They're never served.
+	IncludePaths []string
+
+	// Renderers of extensions other than .md and .html (i.e: ".txt" to
+	// RENDERER_PRE), pages of those extensions are served like any other.
+	Renderers map[string]string
+
+	// What to do with a request that only matches a file of an extension
+	// without a renderer: UNKNOWN_EXTENSIONS_NOT_FOUND (the default) or
+	// UNKNOWN_EXTENSIONS_RAW to serve it as it is.
+	UnknownExtensions string
+
+	// How long to wait for a remote include, 5 seconds if 0.
+	RemoteIncludeTimeout time.Duration
+
+	// Largest remote include, in bytes, 1MB if 0.
+	RemoteIncludeMaxBytes int64
+
+	// Site data (see LoadData), handed to every page as Page.Data. Use
+	// SetData to replace it while pages are being built.
+	Data map[string]interface{}
+
+	// How many files bulk operations (AllURLs, BuildSitemap,
+	// BuildGroupedIndex) read at a time, GOMAXPROCS if 0.
+	Concurrency int
+
+	// Whether links end with a slash: LINK_STYLE_MIXED (the default),
+	// LINK_STYLE_SLASH or LINK_STYLE_PLAIN.
+	LinkStyle string
+
+	// Encoding of content files that are not valid UTF-8, "iso-8859-1" (or
+	// "latin1") or "windows-1252". Such files can't be read if empty.
+	SourceEncoding string
+
+	// Most entries shown on each level of a menu (and on side menus), not
+	// counting the "more…" entry appended when some are left out, which
+	// links to the directory's listing. No limit if 0.
+	MaxItemsPerLevel int
+
+	// Whether the URL a page with a "slug" would have without it redirects to
+	// the slug (with MOVED_TRANSFORM), it's not found otherwise.
+	SlugRedirects bool
+
+	// Whether titles made from file names start with a capital letter, true
+	// by default. Otherwise only separators are replaced by spaces.
+	CapitalizeTitles bool
+
+	// Whether ordering prefixes (i.e: "01-" in "01-intro.md") are left out
+	// of titles made from file names, links keep them.
+	StripOrderPrefix bool
+
+	// Pattern of ordering prefixes, `^\d+[-_.]` if empty. Invalid patterns
+	// are logged and strip nothing.
+	OrderPrefixPattern string
+
+	// Page the root URL is served with (i.e: "welcome.md"), relative to the
+	// content root, instead of the root's index.
+	HomeDocument string
+
+	// Whether the lines of code blocks are numbered.
+	CodeLineNumbers bool
+
+	// Languages of fenced code blocks that are diagrams (i.e: "mermaid"),
+	// they're left in a <div> of that class instead of a <pre>.
+	DiagramLanguages []string
+
+	// Levels of the headings pages are named after when they have no title
+	// on their front matter, the first one within the range is taken (1 and
+	// 2 if they're not set).
+	MinTitleLevel int
+	MaxTitleLevel int
+
+	// Whether Page.ModTime is the date of the file's last commit, when the
+	// content root is a git repository. It runs git once for every page.
+	GitModTime bool
+
+	// Whether directory indexes have a side menu of the pages next to them,
+	// true by default.
+	IndexShowsSideMenu bool
+
+	// What happens when pages of a directory want the same slug:
+	// SLUG_COLLISION_FIRST (the default), SLUG_COLLISION_SUFFIX or
+	// SLUG_COLLISION_ERROR.
+	SlugCollisions string
+
+	// A second content root (i.e: staging only pages) whose files shadow or
+	// add to those under Root, when pages are resolved and menus are built.
+	// Paths stay those under Root. Changes to it are not watched.
+	Overlay string
+
+	// Other content roots, by the path under Root they are served at (i.e:
+	// "plugins" for plugin docs served under /plugins/). Their pages are
+	// resolved, built and listed as if they were in that directory. Changes
+	// to them are not watched.
+	Mounts map[string]string
+
+	// Whether the TopMenu of pages within a top level section (i.e: a product
+	// of a site with many) is that section's menu instead of the root's.
+	SectionTopMenu bool
+
+	// Whether a page's Prev and Next follow the reading order of the whole
+	// site, instead of that of its directory alone.
+	PrevNextAcrossSections bool
+
+	// Whether the rendered content of pages begins with an HTML comment
+	// naming their file (i.e: <!-- source: guide/intro.md -->), for
+	// debugging. Keep it off on production sites.
+	DebugSourceComments bool
+
+	// Whether Export writes a gzip-compressed copy of every file, with a
+	// ".gz" suffix, next to it.
+	CompressOutput bool
+
+	// Whether built pages are kept, and handed out by Build until one of the
+	// files under the content root changes (see WarmCache).
+	CachePages bool
+
+	// Menus already built, by directory, and what each of them was built from.
+	menuCache map[string][]map[string]interface{}
+	menuDeps  map[string]*dependencies
+	pageCache map[string]*pageEntry
+	slugCache map[string]*slugTable
+	stats     CacheStats
+	mu        sync.Mutex
+
+	// OrderPrefixPattern, compiled.
+	orderPrefix *orderPrefix
+}
+
+// Creates and returns a builder for the given content root.
+func NewBuilder(root string) (*Builder, error) {
+	stat, err := os.Stat(root)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error trying to open content root %s: %s", root, err.Error())
+	}
+
+	if stat.IsDir() == false {
+		return nil, fmt.Errorf("Content root %s is not a directory.", root)
+	}
+
+	b := &Builder{
+		Root:               strings.TrimRight(root, PS),
+		IndexPrecedence:    []string{"index.md", "index.html"},
+		CapitalizeTitles:   true,
+		IndexShowsSideMenu: true,
+		AutoIndex:          true,
+		menuCache:          make(map[string][]map[string]interface{}),
+		menuDeps:           make(map[string]*dependencies),
+		pageCache:          make(map[string]*pageEntry),
+		Markdown: MarkdownOptions{
+			Tables:          true,
+			Strikethrough:   true,
+			Autolink:        true,
+			SmartTypography: true,
+			DefinitionLists: true,
+		},
+	}
+
+	return b, nil
+}
+
+// Returns a page for the given file under the content root, with its paths
+// already set.
+func (b *Builder) NewPage(file string) *Page {
+	p := &Page{builder: b, Data: b.siteData()}
+
+	p.FilePath = file
+
+	relPath := file[len(b.Root):]
+
+	p.FileDir = strings.TrimRight(path.Dir(file), PS) + PS
+	p.BasePath = strings.TrimRight(path.Dir(relPath), PS) + PS
+	p.Link = b.linkFor(path.Base(relPath), false, p.BasePath)
+
+	// A page that shares its name with a directory is the directory's index
+	// (see findIndex), it's built as if it was in it.
+	if dir := removeKnownExtension(file); dir != file && isShadowed(p.FileDir, path.Base(file)) {
+		if index, found := b.findIndex(dir); found && index == file {
+			p.FileDir = dir + PS
+			p.Link = b.linkFor(path.Base(dir), true, p.BasePath)
+			p.BasePath = p.BasePath + path.Base(dir) + PS
+			p.shadows = true
+		}
+	}
+
+	// The HomeDocument is built as the root's index, wherever it is.
+	if b.isHomeDocument(file) {
+		if p.BasePath != "/" {
+			p.linkBase = p.BasePath
+		}
+		p.FileDir = b.Root + PS
+		p.BasePath = "/"
+		p.Link = "/"
+	}
+	p.ActiveSection = strings.SplitN(strings.Trim(p.BasePath, PS), PS, 2)[0]
+
+	return p
+}
+
+// Replaces the site data handed to the pages built from now on, it's safe to
+// call while other goroutines are building pages.
+func (b *Builder) SetData(data map[string]interface{}) {
+	b.mu.Lock()
+	b.Data = data
+	b.mu.Unlock()
+}
+
+// Returns the current site data.
+func (b *Builder) siteData() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Data
+}
+
+// Forgets every cached menu and page.
+func (b *Builder) InvalidateCache() {
+	b.mu.Lock()
+	b.menuCache = make(map[string][]map[string]interface{})
+	b.menuDeps = make(map[string]*dependencies)
+	b.pageCache = make(map[string]*pageEntry)
+	b.slugCache = nil
+	b.mu.Unlock()
+}
+
+// Forgets only the cached menus that could have changed because of the given
+// files being added, modified or removed. Pages are made of menus, headers,
+// footers and includes, so every cached page is forgotten.
+func (b *Builder) InvalidateFiles(files []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(files) > 0 {
+		b.pageCache = make(map[string]*pageEntry)
+		b.slugCache = nil
+	}
+	for key, deps := range b.menuDeps {
+		for _, file := range files {
+			if deps.affectedBy(file) {
+				delete(b.menuCache, key)
+				delete(b.menuDeps, key)
+				break
+			}
+		}
+	}
+}
+
+// Returns a copy of the menu cached for dir, if any.
+func (b *Builder) cachedMenu(dir string) ([]map[string]interface{}, bool) {
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	menu, ok := b.menuCache[dir]
+	if deps := b.menuDeps[dir]; ok && deps != nil && deps.expires.IsZero() == false && now.Before(deps.expires) == false {
+		delete(b.menuCache, dir)
+		delete(b.menuDeps, dir)
+		ok = false
+	}
+	if ok == false {
+		b.stats.MenuMisses++
+		return nil, false
+	}
+	b.stats.MenuHits++
+	return copyMenu(menu), true
+}
+
+func (b *Builder) cacheMenu(dir string, menu []map[string]interface{}, deps *dependencies) {
+	b.mu.Lock()
+	b.menuCache[dir] = copyMenu(menu)
+	b.menuDeps[dir] = deps
+	b.mu.Unlock()
+}
+
+// The directories a cached artifact was built from.
+type dependencies struct {
+	// Directories whose subdirectories were listed, with the names found.
+	listed map[string][]string
+	// Directories some other file (i.e: _section.yaml) was read from.
+	read map[string]bool
+	// When the artifact stops being valid, as pages are published or
+	// expire (never if zero).
+	expires time.Time
+}
+
+func newDependencies() *dependencies {
+	return &dependencies{
+		listed: map[string][]string{},
+		read:   map[string]bool{},
+	}
+}
+
+func (d *dependencies) list(dir string, files fileList) {
+	names := []string{}
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	d.listed[path.Clean(dir)] = names
+}
+
+func (d *dependencies) readFrom(dir string) {
+	d.read[path.Clean(dir)] = true
+}
+
+// Makes the artifact expire at the given time, if it's sooner than it did.
+func (d *dependencies) expireAt(date time.Time) {
+	if date.IsZero() == false && (d.expires.IsZero() || date.Before(d.expires)) {
+		d.expires = date
+	}
+}
+
+// Tells whether a change to the given file could change the artifact: either
+// the file lives in a directory the artifact was built from or the file was
+// (or is now) under a directory that was not (or is no longer) listed.
+func (d *dependencies) affectedBy(file string) bool {
+	file = path.Clean(file)
+
+	dir := path.Dir(file)
+
+	if _, ok := d.listed[dir]; ok || d.read[dir] {
+		return true
+	}
+
+	for child := dir; child != path.Dir(child); child = path.Dir(child) {
+		names, ok := d.listed[path.Dir(child)]
+		if ok == false {
+			continue
+		}
+		listed := false
+		for _, name := range names {
+			if name == path.Base(child) {
+				listed = true
+			}
+		}
+		stat, err := os.Stat(child)
+		if listed != (err == nil && stat.IsDir()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Copies menu items (and their children) so cached menus can't be modified
+// through the pages they were handed to.
+func copyMenu(menu []map[string]interface{}) []map[string]interface{} {
+	if menu == nil {
+		return nil
+	}
+	out := make([]map[string]interface{}, len(menu))
+	for i, item := range menu {
+		out[i] = copyMeta(item)
+	}
+	return out
+}
+
+// Returns a copy of a front matter (or menu item) that shares no map or slice
+// with it, nil for nil.
+func copyMeta(meta map[string]interface{}) map[string]interface{} {
+	if meta == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(meta))
+	for key, value := range meta {
+		out[key] = copyValue(value)
+	}
+	return out
+}
+
+// Returns a copy of a front matter value, maps and slices are copied all the
+// way down.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copyMeta(v)
+	case map[interface{}]interface{}:
+		out := make(map[interface{}]interface{}, len(v))
+		for key, value := range v {
+			out[key] = copyValue(value)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, value := range v {
+			out[i] = copyValue(value)
+		}
+		return out
+	case []map[string]interface{}:
+		return copyMenu(v)
+	case []string:
+		return append([]string(nil), v...)
+	}
+	return value
+}
+
+// Values of LinkStyle.
+const (
+	// Directory links end with a slash, page links don't (the default).
+	LINK_STYLE_MIXED = "mixed"
+	// Every link ends with a slash.
+	LINK_STYLE_SLASH = "slash"
+	// No link ends with a slash, except for the root.
+	LINK_STYLE_PLAIN = "plain"
+)
+
+// Returns the link of a file or directory named name within the directory
+// whose URL is prefix, according to LinkStyle.
+func (b *Builder) linkFor(name string, isDir bool, prefix string) string {
+	if isDir == true {
+		return b.styleLink(prefix + name + "/")
+	}
+	if b.pageName(name) == "index" {
+		return b.styleLink(prefix)
+	}
+	return b.styleLink(prefix + b.pageName(name))
+}
+
+// Adds or removes the trailing slash of link, according to LinkStyle.
+func (b *Builder) styleLink(link string) string {
+	if b == nil || link == "/" || isExternalLinkPattern.MatchString(link) {
+		return link
+	}
+	switch b.LinkStyle {
+	case LINK_STYLE_SLASH:
+		return strings.TrimRight(link, "/") + "/"
+	case LINK_STYLE_PLAIN:
+		return strings.TrimRight(link, "/")
+	}
+	return link
+}
+
+// Returns the path the content is mounted under, with leading and trailing
+// slashes (i.e: "/docs/", or "/" if there's no Prefix).
+func (b *Builder) mountPath() string {
+	if b == nil || b.Prefix == "" {
+		return "/"
+	}
+	return "/" + b.Prefix + "/"
+}
+
+// Returns the URL a content file is served at, given its path relative to the
+// content root (i.e: "guide/intro.md" becomes "/guide/intro").
+func (b *Builder) URLByPath(contentRelPath string) (string, error) {
+	rel := strings.Trim(path.Clean("/"+contentRelPath), "/")
+
+	stat, err := os.Stat(b.source(b.Root + PS + rel))
+
+	if err != nil {
+		return "", fmt.Errorf("Could not find content file %s: %s", contentRelPath, err.Error())
+	}
+
+	prefix := b.mountPath()
+
+	if rel == "" || b.isHomeDocument(b.Root+PS+rel) {
+		return b.styleLink(prefix), nil
+	}
+
+	dir, name := path.Split(rel)
+
+	if stat.IsDir() == false && b.keepsExtension(b.Root+PS+dir, name, b.metaOf(b.source(b.Root+PS+rel))) {
+		return prefix + rel, nil
+	}
+
+	if meta := b.metaOf(b.source(b.Root + PS + rel)); stat.IsDir() == false && slugOf(meta) != "" && removeKnownExtension(name) != "index" {
+		served, err := b.servedName(b.Root+PS+dir, name, meta)
+		if err != nil {
+			return "", err
+		}
+		return b.linkFor(served, false, prefix+dir), nil
+	}
+
+	if stat.IsDir() == false && isShadowed(b.Root+PS+dir, name) {
+		// Served as the index of the directory with the same name.
+		return b.linkFor(removeKnownExtension(name), true, prefix+dir), nil
+	}
+
+	return b.linkFor(name, stat.IsDir(), prefix+dir), nil
+}
@@ -0,0 +1,264 @@
+package page
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Writes the given files (relative path => content) under a new temporary
+// directory and returns its path.
+func fixture(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func testBuilder(t *testing.T, files map[string]string) *Builder {
+	b, err := NewBuilder(fixture(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestURLByPath(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":         "# Home",
+		"guide/index.md":   "# Guide",
+		"guide/intro.md":   "# Intro",
+		"guide/table.html": "<h1>Table</h1>",
+	})
+
+	tests := map[string]string{
+		"guide/intro.md":   "/guide/intro",
+		"guide/table.html": "/guide/table",
+		"guide/index.md":   "/guide/",
+		"guide":            "/guide/",
+		"index.md":         "/",
+	}
+
+	for rel, expected := range tests {
+		url, err := b.URLByPath(rel)
+		if err != nil {
+			t.Fatalf("%s: %s", rel, err)
+		}
+		if url != expected {
+			t.Fatalf("%s: expecting %q, got %q", rel, expected, url)
+		}
+	}
+
+	b.Prefix = "docs"
+
+	url, _ := b.URLByPath("guide/intro.md")
+	if url != "/docs/guide/intro" {
+		t.Fatalf("Expecting prefixed URL, got %q", url)
+	}
+
+	if _, err := b.URLByPath("guide/missing.md"); err == nil {
+		t.Fatalf("Expecting an error for a missing file.")
+	}
+}
+
+func TestInvalidateFiles(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":            "# Home",
+		"guide/index.md":      "# Guide",
+		"guide/basics/one.md": "# One",
+		"reference/index.md":  "# Reference",
+		"reference/api/x.md":  "# X",
+	})
+
+	pages := map[string]*Page{}
+
+	build := func() {
+		for _, dir := range []string{"", "guide", "reference"} {
+			p := b.NewPage(filepath.Join(b.Root, dir, "index.md"))
+			p.CreateMenu()
+			pages[dir] = p
+		}
+	}
+
+	cached := func(dir string) bool {
+		_, ok := b.cachedMenu(pages[dir].FileDir + pages[dir].BasePath)
+		return ok
+	}
+
+	build()
+
+	b.InvalidateFiles([]string{filepath.Join(b.Root, "reference", "api", "x.md")})
+
+	if cached("") || cached("reference") {
+		t.Fatalf("Expecting the menus depending on reference/api to be invalidated.")
+	}
+
+	if cached("guide") == false {
+		t.Fatalf("Expecting the guide menu to remain cached.")
+	}
+
+	build()
+
+	file := filepath.Join(b.Root, "guide", "new", "page.md")
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(file, []byte("# Page"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b.InvalidateFiles([]string{file})
+
+	if cached("") || cached("guide") {
+		t.Fatalf("Expecting the menus listing guide to be invalidated by a new directory.")
+	}
+
+	if cached("reference") == false {
+		t.Fatalf("Expecting the reference menu to remain cached.")
+	}
+}
+
+func TestLinkStyle(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":       "# Home",
+		"guide/index.md": "# Guide",
+		"guide/intro.md": "# Intro",
+	})
+
+	p := b.NewPage(filepath.Join(b.Root, "index.md"))
+
+	guide, _ := os.Stat(filepath.Join(b.Root, "guide"))
+	intro, _ := os.Stat(filepath.Join(b.Root, "guide", "intro.md"))
+
+	tests := []struct {
+		style string
+		dir   string
+		file  string
+	}{
+		{"", "/guide/", "/guide/intro"},
+		{LINK_STYLE_MIXED, "/guide/", "/guide/intro"},
+		{LINK_STYLE_SLASH, "/guide/", "/guide/intro/"},
+		{LINK_STYLE_PLAIN, "/guide", "/guide/intro"},
+	}
+
+	for _, test := range tests {
+		b.LinkStyle = test.style
+
+		if link := p.CreateLink(guide, "/")["link"]; link != test.dir {
+			t.Fatalf("%q: expecting directory link %q, got %q", test.style, test.dir, link)
+		}
+
+		if link := p.CreateLink(intro, "/guide/")["link"]; link != test.file {
+			t.Fatalf("%q: expecting file link %q, got %q", test.style, test.file, link)
+		}
+
+		if url, _ := b.URLByPath("index.md"); url != "/" {
+			t.Fatalf("%q: expecting the root to stay /, got %q", test.style, url)
+		}
+
+		if file, transform := b.Resolve(b.Root + test.file); transform != MARKDOWN_TRANSFORM || file != filepath.Join(b.Root, "guide", "intro.md") {
+			t.Fatalf("%q: expecting %s to resolve to intro.md, got %s (%d)", test.style, test.file, file, transform)
+		}
+
+		if file, transform := b.Resolve(b.Root + test.dir); transform != MARKDOWN_TRANSFORM || file != filepath.Join(b.Root, "guide", "index.md") {
+			t.Fatalf("%q: expecting %s to resolve to the guide's index, got %s (%d)", test.style, test.dir, file, transform)
+		}
+	}
+}
+
+func TestURLByPathAgreesWithMenus(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":                "# Home",
+		"guide/index.md":          "# Guide",
+		"guide/01-intro.md":       "# Intro",
+		"guide/moved.md":          "---\nslug: elsewhere\n---\n# Moved",
+		"guide/setup.rst":         "Setup\n=====\n",
+		"guide/notes.txt":         "Notes\n",
+		"guide/raw.html":          "---\nkeep_extension: true\n---\n<h1>Raw</h1>",
+		"guide/advanced.md":       "# Advanced",
+		"guide/advanced/index.md": "# Advanced",
+		"guide/advanced/deep.md":  "# Deep",
+	})
+
+	b.Renderers = map[string]string{".txt": RENDERER_PRE}
+	b.StripOrderPrefix = true
+	b.Mounts = map[string]string{
+		"guide/extra": fixture(t, map[string]string{"tips.md": "# Tips"}),
+	}
+
+	for _, style := range []string{LINK_STYLE_MIXED, LINK_STYLE_SLASH, LINK_STYLE_PLAIN} {
+		for _, prefix := range []string{"", "docs"} {
+			b.LinkStyle = style
+			b.Prefix = prefix
+			b.InvalidateCache()
+
+			p, err := b.Build(filepath.Join(b.Root, "guide", "index.md"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			links := map[string]bool{}
+			for _, item := range p.SideMenu {
+				links[item["link"].(string)] = true
+			}
+
+			items, err := b.BuildListing("guide", true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			listing := map[string]bool{}
+			for _, item := range items {
+				listing[item["link"].(string)] = true
+			}
+
+			urls, err := b.AllURLs(filepath.Join(b.Root, "guide"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			listed := map[string]bool{}
+			for _, url := range urls {
+				listed[url] = true
+			}
+
+			for _, rel := range []string{"guide/01-intro.md", "guide/moved.md", "guide/setup.rst", "guide/notes.txt", "guide/raw.html"} {
+				url, err := b.URLByPath(rel)
+				if err != nil {
+					t.Fatalf("%s: %s", rel, err)
+				}
+				// Menus and listings link within the Prefix.
+				link := "/" + strings.TrimPrefix(url, b.mountPath())
+				if links[link] == false {
+					t.Fatalf("%s (%s, %q): expecting %q on the side menu, got %v", rel, style, prefix, link, links)
+				}
+				if listing[link] == false {
+					t.Fatalf("%s (%s, %q): expecting %q on the listing, got %v", rel, style, prefix, link, listing)
+				}
+				if listed[url] == false {
+					t.Fatalf("%s (%s, %q): expecting %q among %v", rel, style, prefix, url, urls)
+				}
+			}
+
+			// Directories, mounted ones and those shadowing a page.
+			for _, rel := range []string{"guide/advanced", "guide/advanced.md", "guide/extra"} {
+				url, err := b.URLByPath(rel)
+				if err != nil {
+					t.Fatalf("%s: %s", rel, err)
+				}
+				if link := "/" + strings.TrimPrefix(url, b.mountPath()); listing[link] == false {
+					t.Fatalf("%s (%s, %q): expecting %q on the listing, got %v", rel, style, prefix, link, listing)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,526 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Extensions tried, in order, when looking for _header and _footer files if
+// the builder has no IncludePrecedence.
+var includeExtensions = []string{
+	".md",
+	".html",
+	".txt",
+}
+
+var (
+	headingPattern = regexp.MustCompile(`<h([1-6])[^>]*>(.+?)</h[1-6]>`)
+	tagPattern     = regexp.MustCompile(`<[^>]+>`)
+)
+
+// Returns the file to include for base (i.e: "/webroot/_header"), the first
+// of base plus each of the IncludePrecedence extensions that exists.
+func (b *Builder) findInclude(base string) (string, bool) {
+	extensions := b.IncludePrecedence
+	if len(extensions) == 0 {
+		extensions = includeExtensions
+	}
+
+	for _, extension := range extensions {
+		file := b.source(base + extension)
+		stat, err := os.Stat(file)
+		if err == nil && stat.IsDir() == false {
+			return file, true
+		}
+	}
+
+	return "", false
+}
+
+// Reads a file and returns its front matter, if any, and the rest of its
+// source. Files that are not valid UTF-8 can't be read.
+func readSource(file string) (map[string]interface{}, []byte, error) {
+	return readSourceAs(file, "", 0)
+}
+
+// Like readSource, files that are not valid UTF-8 are transcoded from the
+// builder's SourceEncoding and front matter profiles are merged in (see
+// extendMeta). Files larger than MaxContentBytes are not read.
+func (b *Builder) readSource(file string) (map[string]interface{}, []byte, error) {
+	if b == nil {
+		return readSource(file)
+	}
+
+	meta, _, src, err := b.readPageSource(file)
+
+	return meta, src, err
+}
+
+// Like Builder.readSource, also returns the front matter as it was written,
+// before profiles are merged in.
+func (b *Builder) readPageSource(file string) (map[string]interface{}, map[string]interface{}, []byte, error) {
+	raw, src, err := readSourceAs(file, b.SourceEncoding, b.MaxContentBytes)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	meta, err := b.extendMeta(raw)
+
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Could not parse front matter of %s: %s", file, err.Error())
+	}
+
+	return meta, raw, src, nil
+}
+
+// Returns the front matter of a file, with profiles merged in, reading only
+// as much of it as needed: menus, listings and sitemaps don't need the
+// content of pages, however large they are.
+func (b *Builder) readMeta(file string) (map[string]interface{}, error) {
+	if b == nil {
+		return readMetaAs(file, "")
+	}
+
+	raw, err := readMetaAs(file, b.SourceEncoding)
+
+	if err != nil || raw == nil {
+		return raw, err
+	}
+
+	meta, err := b.extendMeta(raw)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse front matter of %s: %s", file, err.Error())
+	}
+
+	return meta, nil
+}
+
+func readMetaAs(file string, encoding string) (map[string]interface{}, error) {
+	stat, err := os.Stat(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if stat.IsDir() {
+		return nil, nil
+	}
+
+	fp, err := os.Open(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer fp.Close()
+
+	reader := bufio.NewReader(fp)
+
+	head := []byte{}
+
+	// Up to the closing delimiter, or just the first line if it's not a
+	// delimiter.
+	for first := true; ; first = false {
+		line, err := reader.ReadBytes('\n')
+		head = append(head, line...)
+		if err != nil || first != bytes.Equal(bytes.TrimSpace(line), frontMatterDelimiter) {
+			break
+		}
+	}
+
+	head, err = toUTF8(head, encoding)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not read %s: %s", file, err.Error())
+	}
+
+	meta, _, err := splitFrontMatter(head)
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse front matter of %s: %s", file, err.Error())
+	}
+
+	return meta, nil
+}
+
+func readSourceAs(file string, encoding string, limit int64) (map[string]interface{}, []byte, error) {
+	stat, err := os.Stat(file)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if stat.IsDir() == false {
+
+		if limit > 0 && stat.Size() > limit {
+			return nil, nil, fmt.Errorf("Refusing to read %s: it has %d bytes, the limit is %d.", file, stat.Size(), limit)
+		}
+
+		fp, err := os.Open(file)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		defer fp.Close()
+
+		buf := make([]byte, stat.Size())
+
+		_, err = fp.Read(buf)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		buf, err = toUTF8(buf, encoding)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("Could not read %s: %s", file, err.Error())
+		}
+
+		meta, src, err := splitFrontMatter(buf)
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("Could not parse front matter of %s: %s", file, err.Error())
+		}
+
+		return meta, src, nil
+	}
+
+	return nil, nil, nil
+}
+
+// Returns the HTML for the given source, after expanding its includes. The
+// source is rendered by the renderer of the file's extension: markdown for
+// .md, reStructuredText for .rst and those of the Renderers, files of other
+// extensions are used as they are.
+func (b *Builder) render(file string, src []byte) []byte {
+	out := b.expandIncludes(file, src, 0)
+
+	var code [][]int
+	if b.rendererOf(file) == RENDERER_MARKDOWN {
+		code = markdownCode(out)
+	}
+
+	out = expandEnvBlocks(out, b.Environment, code)
+
+	switch b.rendererOf(file) {
+	case RENDERER_MARKDOWN:
+		out = b.markdown(out)
+	case RENDERER_PRE:
+		out = renderPre(out)
+	case RENDERER_RST:
+		out = b.rst(out)
+	}
+
+	if b.EmojiReplace {
+		// Like smart typography, code and attribute values are left alone.
+		out = []byte(replaceText(string(out), expandEmoji))
+	}
+
+	return out
+}
+
+// Returns an error if the given file is larger than MaxContentBytes, without
+// reading it.
+func (b *Builder) checkSize(file string) error {
+	if b.MaxContentBytes <= 0 {
+		return nil
+	}
+
+	stat, err := os.Stat(file)
+
+	if err != nil {
+		return err
+	}
+
+	if stat.Size() > b.MaxContentBytes {
+		return fmt.Errorf("Refusing to render %s: it has %d bytes, the limit is %d.", file, stat.Size(), b.MaxContentBytes)
+	}
+
+	return nil
+}
+
+// Reads a file, if the file has the .md extension the contents are parsed and HTML is returned.
+func (b *Builder) readFile(file string) ([]byte, error) {
+	if err := b.checkSize(file); err != nil {
+		return nil, err
+	}
+
+	_, src, err := b.readSource(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b.render(file, src), nil
+}
+
+// Returns the text of the first heading of the given HTML between
+// MinTitleLevel and MaxTitleLevel, exactly as it was written (tags are
+// removed, entities are decoded).
+func (b *Builder) extractTitle(content string) string {
+	min, max := 1, 2
+
+	if b != nil && b.MinTitleLevel > 0 {
+		min = b.MinTitleLevel
+	}
+	if b != nil && b.MaxTitleLevel > 0 {
+		max = b.MaxTitleLevel
+	}
+
+	for _, found := range headingPattern.FindAllStringSubmatch(content, -1) {
+		if level, _ := strconv.Atoi(found[1]); level >= min && level <= max {
+			return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(found[2], "")))
+		}
+	}
+
+	return ""
+}
+
+// Returns a title derived from the name of the given file, index files are
+// named after their directory.
+func (b *Builder) fileTitle(file string) string {
+	name := path.Base(file)
+	if removeKnownExtension(name) == "index" {
+		dir := path.Base(path.Dir(file))
+		if dir == "." || dir == "/" {
+			return "Home"
+		}
+		name = dir
+	}
+	return b.createTitle(name)
+}
+
+var numericTitlePattern = regexp.MustCompile(`^[\d\s.]*$`)
+
+// Tells whether a title made from a file name says anything.
+func isMeaningfulTitle(title string) bool {
+	return numericTitlePattern.MatchString(title) == false
+}
+
+// Resolves links relative to the current document's directory, rooted and
+// external links are left as they are.
+func (p *Page) assetLinks(links []string) []string {
+	out := []string{}
+	for _, link := range links {
+		if isExternalLinkPattern.MatchString(link) || strings.HasPrefix(link, "/") {
+			out = append(out, link)
+		} else {
+			out = append(out, path.Clean(p.BasePath+link))
+		}
+	}
+	return out
+}
+
+// Returns the HTML of the page's content, from the given source, and its
+// lead. The lead is left out of the content if RemoveLead is set.
+func (p *Page) renderContent(src []byte) (template.HTML, template.HTML) {
+	content := p.builder.markTOC(p.absoluteLinks(string(p.builder.render(p.FilePath, src))))
+
+	lead, rest := extractLead(content)
+
+	if p.builder.RemoveLead {
+		content = rest
+	}
+
+	return template.HTML(content), template.HTML(lead)
+}
+
+// Reads the given file and returns a page with its content, header, footer,
+// title, breadcrumb and menus. With CachePages set, pages already built are
+// returned from the cache.
+func (b *Builder) Build(file string) (*Page, error) {
+	if b.CachePages {
+		if p, ok := b.cachedPage(file); ok {
+			return p, nil
+		}
+	}
+
+	p := b.NewPage(file)
+
+	if err := b.checkSize(b.source(file)); err != nil {
+		return nil, err
+	}
+
+	meta, raw, src, err := b.readPageSource(b.source(file))
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.Meta = meta
+	p.meta = meta
+	// A copy, Meta is the very same map when the page extends no profile,
+	// and shares its nested values otherwise.
+	p.FrontMatter = copyMeta(raw)
+	p.ModTime = b.modTime(b.source(file))
+
+	if slugOf(meta) != "" && removeKnownExtension(path.Base(file)) != "index" {
+		served, err := b.servedName(path.Dir(file), path.Base(file), meta)
+		if err != nil {
+			return nil, err
+		}
+		p.Link = b.linkFor(served, false, p.BasePath)
+	}
+	p.Description = metaString(meta, "description")
+	p.Robots = metaString(meta, "robots")
+	p.Weight = metaInt(meta, "weight")
+	p.Styles = p.assetLinks(metaStrings(meta, "styles"))
+	p.Scripts = p.assetLinks(metaStrings(meta, "scripts"))
+	p.Content, p.Lead = p.renderContent(src)
+	p.postRender()
+
+	if b.DebugSourceComments {
+		source := strings.Replace(b.relPath(file), "--", "- -", -1)
+		p.Content = template.HTML("<!-- source: "+source+" -->\n") + p.Content
+	}
+
+	// werc-like header and footer.
+	hfile, hfound := b.findInclude(p.FileDir + "_header")
+
+	if hfound {
+		hcontent, herr := b.readFile(hfile)
+		if herr == nil {
+			p.ContentHeader = template.HTML(p.absoluteLinks(string(hcontent)))
+		}
+	}
+
+	if b.HomeDocument != "" {
+		p.IsHome = b.isHomeDocument(file)
+	} else if p.BasePath == "/" {
+		p.IsHome = true
+	}
+
+	if p.Link == "/" {
+		p.HomeSections = b.homeSections()
+	}
+
+	p.Section = p.topSection()
+
+	// werc-like header and footer.
+	ffile, ffound := b.findInclude(p.FileDir + "_footer")
+
+	if ffound {
+		fcontent, ferr := b.readFile(ffile)
+		if ferr == nil {
+			p.ContentFooter = template.HTML(p.absoluteLinks(string(fcontent)))
+		}
+	}
+
+	// Headings are used verbatim, only file names go through createTitle.
+	p.Title = metaString(meta, "title")
+
+	if p.Title == "" {
+		p.Title = b.extractTitle(string(p.Content))
+	}
+
+	if p.Title == "" {
+		p.Title = b.fileTitle(file[len(b.Root):])
+		if b.DefaultTitle != "" && isMeaningfulTitle(p.Title) == false {
+			p.Title = b.DefaultTitle
+		}
+	}
+
+	if b.EmojiReplace {
+		p.Title = expandEmoji(p.Title)
+	}
+
+	p.CreateBreadCrumb()
+	p.CreateParent()
+	p.CreateMenu()
+	p.CreateSideMenu()
+	p.CreatePrevNext()
+	p.CreateSiblingPosition()
+
+	p.ETag = p.etag()
+
+	if b.StrictMode {
+		if problems := p.problems(); len(problems) > 0 {
+			return nil, problems
+		}
+	}
+
+	p.Meta = b.allowedMeta(p.Meta)
+	p.FrontMatter = b.allowedMeta(p.FrontMatter)
+
+	if b.CachePages {
+		b.cachePage(file, p)
+	}
+
+	return p, nil
+}
+
+// Name of the document shown, if it exists on the content root, when a page
+// is not found.
+const notFoundFile = "_404"
+
+// Returns a page for a URL that could not be resolved, its menus and
+// breadcrumb are those of the nearest existing directory above it. Its
+// content comes from _404.md (or .html) on the content root, if any.
+func (b *Builder) BuildNotFound(urlPath string) *Page {
+	dir := path.Clean("/" + urlPath)
+
+	for dir != "/" {
+		stat, err := os.Stat(b.source(b.Root + dir))
+		if err == nil && stat.IsDir() {
+			break
+		}
+		dir = path.Dir(dir)
+	}
+
+	p := b.NewPage(strings.TrimRight(b.Root+dir, "/") + "/index")
+
+	p.IsNotFound = true
+	p.Title = "Not found"
+
+	if file, found := b.findInclude(b.Root + PS + notFoundFile); found {
+		content, err := b.readFile(file)
+		if err == nil {
+			p.Content = template.HTML(content)
+			if title := b.extractTitle(string(p.Content)); title != "" {
+				p.Title = title
+			}
+		}
+	}
+
+	p.CreateBreadCrumb()
+	p.CreateParent()
+	p.CreateMenu()
+	p.CreateSideMenu()
+
+	return p
+}
@@ -0,0 +1,411 @@
+package page
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildTitle(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"tuning.md":  "# PostgreSQL Tuning\n\nSome text.",
+		"foo-bar.md": "Just some text, no headings.",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "tuning.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "PostgreSQL Tuning" {
+		t.Fatalf("Expecting the heading text verbatim, got %q", p.Title)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "foo-bar.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Foo bar" {
+		t.Fatalf("Expecting a title from the file name, got %q", p.Title)
+	}
+}
+
+func TestTitleLevels(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"setup.md":      "#### Sidebar\n\n## Getting Set Up\n\n# Later",
+		"side-notes.md": "Text.\n\n#### Related",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "setup.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Getting Set Up" {
+		t.Fatalf("Expecting the first H1 or H2, got %q", p.Title)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "side-notes.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Side notes" {
+		t.Fatalf("Expecting the H4 to be skipped, got %q", p.Title)
+	}
+
+	b.MaxTitleLevel = 4
+
+	p, err = b.Build(filepath.Join(b.Root, "setup.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Sidebar" {
+		t.Fatalf("Expecting the H4 within the range, got %q", p.Title)
+	}
+}
+
+func TestDebugSourceComments(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/intro.md": "# Intro\n\nText.",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "guide", "intro.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(p.Content), "<!-- source:") {
+		t.Fatalf("Expecting no source comment by default, got %q", p.Content)
+	}
+
+	b.DebugSourceComments = true
+
+	p, err = b.Build(filepath.Join(b.Root, "guide", "intro.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(string(p.Content), "<!-- source: guide/intro.md -->\n<h1") == false || p.Title != "Intro" {
+		t.Fatalf("Expecting the source comment before the content, got %q", p.Content)
+	}
+}
+
+func TestDefaultTitle(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"1.md":       "No headings here.",
+		"foo-bar.md": "No headings here either.",
+	})
+
+	b.DefaultTitle = "Untitled"
+
+	p, err := b.Build(filepath.Join(b.Root, "1.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Untitled" {
+		t.Fatalf("Expecting the default title, got %q", p.Title)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "foo-bar.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Foo bar" {
+		t.Fatalf("Expecting a title from the file name, got %q", p.Title)
+	}
+}
+
+func TestAssets(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/charts.md": "---\nstyles: [charts.css, /css/site.css]\nscripts:\n  - js/charts.js\n  - http://cdn.example.org/d3.js\n---\n# Charts\n",
+		"guide/plain.md":  "# Plain\n",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "guide", "charts.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reflect.DeepEqual(p.Styles, []string{"/guide/charts.css", "/css/site.css"}) == false {
+		t.Fatalf("Unexpected styles %v", p.Styles)
+	}
+
+	if reflect.DeepEqual(p.Scripts, []string{"/guide/js/charts.js", "http://cdn.example.org/d3.js"}) == false {
+		t.Fatalf("Unexpected scripts %v", p.Scripts)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "guide", "plain.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Styles) != 0 || len(p.Scripts) != 0 {
+		t.Fatalf("Expecting no assets, got %v and %v", p.Styles, p.Scripts)
+	}
+}
+
+func TestBuildNotFound(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":            "# Home",
+		"_404.md":             "# Page not found\n\nSorry.\n",
+		"guide/index.md":      "# Guide",
+		"guide/intro.md":      "# Intro",
+		"guide/basics/one.md": "# One",
+		"guide/basics/two.md": "# Two",
+		"reference/index.md":  "# Reference",
+	})
+
+	p := b.BuildNotFound("/guide/basics/missing/deeper")
+
+	if p.IsNotFound == false || p.Title != "Page not found" {
+		t.Fatalf("Expecting the _404.md page, got %q", p.Title)
+	}
+
+	crumbs := []string{}
+	for _, crumb := range p.BreadCrumb {
+		crumbs = append(crumbs, crumb["link"].(string))
+	}
+
+	if reflect.DeepEqual(crumbs, []string{"/", "/guide/", "/guide/basics/"}) == false {
+		t.Fatalf("Expecting the breadcrumb of the nearest directory, got %v", crumbs)
+	}
+
+	if len(p.SideMenu) != 2 {
+		t.Fatalf("Expecting the side menu of the nearest directory, got %v", p.SideMenu)
+	}
+
+	p = b.BuildNotFound("/nope")
+
+	if len(p.BreadCrumb) != 1 || p.BreadCrumb[0]["link"] != "/" {
+		t.Fatalf("Expecting a Home only breadcrumb, got %v", p.BreadCrumb)
+	}
+
+	if len(p.Menu) != 2 {
+		t.Fatalf("Expecting the root menu, got %v", p.Menu)
+	}
+}
+
+func TestBuildNotFoundOverlay(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":       "# Home",
+		"_404.md":        "# Page not found\n\nSorry.\n",
+		"guide/index.md": "# Guide",
+	})
+
+	b.Mounts = map[string]string{
+		"plugins": fixture(t, map[string]string{
+			"index.md": "# Plugins",
+			"one.md":   "# One",
+			"two.md":   "# Two",
+		}),
+	}
+	b.Overlay = fixture(t, map[string]string{
+		"extra/index.md": "# Extras",
+		"extra/page.md":  "# Page",
+	})
+
+	for urlPath, expected := range map[string][]string{
+		"/plugins/missing": {"/", "/plugins/"},
+		"/extra/missing":   {"/", "/extra/"},
+	} {
+		p := b.BuildNotFound(urlPath)
+
+		crumbs := []string{}
+		for _, crumb := range p.BreadCrumb {
+			crumbs = append(crumbs, crumb["link"].(string))
+		}
+
+		if reflect.DeepEqual(crumbs, expected) == false {
+			t.Fatalf("Expecting the breadcrumb of %s to be %v, got %v", urlPath, expected, crumbs)
+		}
+
+		if len(p.SideMenu) == 0 {
+			t.Fatalf("Expecting the side menu of the nearest directory of %s, got %v", urlPath, p.SideMenu)
+		}
+	}
+}
+
+func TestMaxContentBytes(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"small.md": "# Small",
+		"large.md": "# Large\n\nThis one has a lot more to say than the limit allows.",
+	})
+
+	b.MaxContentBytes = 16
+
+	if _, err := b.Build(filepath.Join(b.Root, "large.md")); err == nil || strings.Contains(err.Error(), "large.md") == false {
+		t.Fatalf("Expecting an error naming the large file, got %v", err)
+	}
+
+	p, err := b.Build(filepath.Join(b.Root, "small.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(p.Content), "<h1>Small</h1>") == false {
+		t.Fatalf("Expecting the small file to be rendered, got %q", p.Content)
+	}
+}
+
+func TestMaxContentBytesFrontMatter(t *testing.T) {
+	body := strings.Repeat("Too much to read. ", 1000)
+
+	b := testBuilder(t, map[string]string{
+		"guide/index.md":  "# Guide",
+		"guide/large.md":  "---\nmenu_title: Large\n---\n" + body,
+		"guide/hidden.md": "---\ndraft: true\n---\n" + body,
+		"guide/plain.md":  "----\n" + body,
+	})
+
+	b.MaxContentBytes = 64
+
+	if _, _, err := b.readSource(filepath.Join(b.Root, "guide", "large.md")); err == nil || strings.Contains(err.Error(), "Refusing") == false {
+		t.Fatalf("Expecting large files not to be read, got %v", err)
+	}
+
+	meta, err := b.readMeta(filepath.Join(b.Root, "guide", "large.md"))
+	if err != nil || meta["menu_title"] != "Large" {
+		t.Fatalf("Expecting the front matter of a large file, got %v (%v)", meta, err)
+	}
+
+	if meta, err := b.readMeta(filepath.Join(b.Root, "guide", "plain.md")); err != nil || len(meta) != 0 {
+		t.Fatalf("Expecting no front matter without a delimiter, got %v (%v)", meta, err)
+	}
+
+	p := b.NewPage(filepath.Join(b.Root, "guide", "index.md"))
+	p.CreateSideMenu()
+
+	texts := []string{}
+	for _, item := range p.SideMenu {
+		texts = append(texts, fmt.Sprint(item["text"]))
+	}
+
+	// Drafts are still left out, menu titles still apply.
+	if reflect.DeepEqual(texts, []string{"Large", "Plain"}) == false {
+		t.Fatalf("Expecting the front matter of large files to be used on menus, got %v", texts)
+	}
+}
+
+func TestHeaderPrecedence(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"both/_header.md":   "**Markdown** header",
+		"both/_header.html": "<b>HTML</b> header",
+		"both/page.md":      "# Page",
+		"html/_header.html": "<b>HTML</b> *header*",
+		"html/page.md":      "# Page",
+	})
+
+	header := func(dir string) string {
+		p, err := b.Build(filepath.Join(b.Root, dir, "page.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(p.ContentHeader))
+	}
+
+	if h := header("both"); h != "<p><strong>Markdown</strong> header</p>" {
+		t.Fatalf("Expecting _header.md to win by default, got %q", h)
+	}
+
+	if h := header("html"); h != "<b>HTML</b> *header*" {
+		t.Fatalf("Expecting _header.html to be included as it is, got %q", h)
+	}
+
+	b.IncludePrecedence = []string{".html", ".md"}
+
+	if h := header("both"); h != "<b>HTML</b> header" {
+		t.Fatalf("Expecting _header.html to win, got %q", h)
+	}
+}
+
+func TestSourceEncoding(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"cafe.md": "# Caf\xe9\n\nCr\xe8me br\xfbl\xe9e.\n",
+	})
+
+	file := filepath.Join(b.Root, "cafe.md")
+
+	if _, err := b.Build(file); err == nil || strings.Contains(err.Error(), "UTF-8") == false {
+		t.Fatalf("Expecting an error about the encoding, got %v", err)
+	}
+
+	b.SourceEncoding = "latin1"
+
+	p, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Title != "Café" || strings.Contains(string(p.Content), "Crème brûlée.") == false {
+		t.Fatalf("Expecting the file to be transcoded, got %q and %q", p.Title, p.Content)
+	}
+}
+
+func TestSourceEncodingEverywhere(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":             "# Home\n\n{{ include \"_snippets/menu.md\" }}\n",
+		"_snippets/menu.md":    "Cr\xe8me",
+		"cafe/index.md":        "---\ntitle: Caf\xe9\ntags: [d\xe9j\xe0]\n---\n# Caf\xe9\n",
+		"cafe/creme-brulee.md": "# Cr\xe8me br\xfbl\xe9e\n",
+	})
+
+	b.SourceEncoding = "latin1"
+
+	urls, err := b.AllURLs(b.Root)
+	if err != nil || reflect.DeepEqual(urls, []string{"/", "/cafe/", "/cafe/creme-brulee"}) == false {
+		t.Fatalf("Expecting every page, got %v (%v)", urls, err)
+	}
+
+	if _, err := b.BuildSitemap(b.Root, "http://example.org"); err != nil {
+		t.Fatal(err)
+	}
+
+	if tags, err := b.TagCounts(b.Root); err != nil || tags["déjà"] != 1 {
+		t.Fatalf("Expecting transcoded tags, got %v (%v)", tags, err)
+	}
+
+	if problems, err := b.ValidateFrontMatter(b.Root, []string{"title"}); err != nil || len(problems) != 2 {
+		t.Fatalf("Expecting only the missing titles to be reported, got %v (%v)", problems, err)
+	}
+
+	p, err := b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Menu) != 1 || p.Menu[0]["text"] != "Café" {
+		t.Fatalf("Expecting the index title on the menu, got %v", p.Menu)
+	}
+
+	if strings.Contains(string(p.Content), "Crème") == false {
+		t.Fatalf("Expecting the include to be transcoded, got %q", p.Content)
+	}
+}
+
+func TestCapitalizeTitles(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/getting-started.md": "No headings.",
+	})
+
+	title := func() string {
+		p, err := b.Build(filepath.Join(b.Root, "guide", "getting-started.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.Title
+	}
+
+	if title := title(); title != "Getting started" {
+		t.Fatalf("Expecting a capitalized title by default, got %q", title)
+	}
+
+	b.CapitalizeTitles = false
+
+	if title := title(); title != "getting started" {
+		t.Fatalf("Expecting the file name's case to be kept, got %q", title)
+	}
+
+	p := b.NewPage(filepath.Join(b.Root, "guide", "getting-started.md"))
+	p.CreateBreadCrumb()
+
+	if text := p.BreadCrumb[1]["text"]; text != "guide" {
+		t.Fatalf("Expecting the crumb's case to be kept, got %v", text)
+	}
+}
@@ -0,0 +1,138 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Directory, under the content root, that holds the site's data files.
+const dataDir = "_data"
+
+// Reads every .yaml, .yml and .json file under the _data directory of root
+// and returns their contents keyed by file name (without extension), files
+// within subdirectories are nested under the subdirectory's name.
+func LoadData(root string) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+
+	dir := filepath.Join(root, dataDir)
+
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return data, nil
+		}
+		return nil, fmt.Errorf("Error trying to open data directory %s: %s", dir, err.Error())
+	}
+
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if isHidden(info.Name()) && file != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		ext := filepath.Ext(file)
+
+		if info.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			return nil
+		}
+
+		buf, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			return fmt.Errorf("Error trying to read data file %s: %s", file, err.Error())
+		}
+
+		var value interface{}
+
+		if ext == ".json" {
+			err = json.Unmarshal(buf, &value)
+		} else {
+			err = yaml.Unmarshal(buf, &value)
+		}
+
+		if err != nil {
+			return fmt.Errorf("Could not parse data file %s: %s", file, err.Error())
+		}
+
+		rel, _ := filepath.Rel(dir, file)
+
+		chunks := strings.Split(filepath.ToSlash(strings.TrimSuffix(rel, ext)), "/")
+
+		node := data
+		for _, chunk := range chunks[:len(chunks)-1] {
+			child, ok := node[chunk].(map[string]interface{})
+			if ok == false {
+				child = map[string]interface{}{}
+				node[chunk] = child
+			}
+			node = child
+		}
+
+		node[chunks[len(chunks)-1]] = stringKeys(value)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Converts the map[interface{}]interface{} values YAML produces into
+// map[string]interface{}, so data files look the same whatever their format.
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		out := map[string]interface{}{}
+		for key, item := range v {
+			out[fmt.Sprintf("%v", key)] = stringKeys(item)
+		}
+		return out
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = stringKeys(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	}
+	return value
+}
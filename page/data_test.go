@@ -0,0 +1,86 @@
+package page
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadData(t *testing.T) {
+	root := fixture(t, map[string]string{
+		"index.md": "# Home",
+		"_data/team.yaml": "- name: Ana\n  role: Editor\n" +
+			"- name: Bo\n  role: Writer\n  links:\n    site: https://bo.example.org\n",
+		"_data/faq/general.json": `[{"q": "Why?", "a": "Because."}]`,
+		"_data/notes.txt":        "Ignored.",
+	})
+
+	data, err := LoadData(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	team := []interface{}{
+		map[string]interface{}{"name": "Ana", "role": "Editor"},
+		map[string]interface{}{
+			"name":  "Bo",
+			"role":  "Writer",
+			"links": map[string]interface{}{"site": "https://bo.example.org"},
+		},
+	}
+
+	if reflect.DeepEqual(data["team"], team) == false {
+		t.Fatalf("Unexpected team data %#v", data["team"])
+	}
+
+	faq, ok := data["faq"].(map[string]interface{})
+	if ok == false || len(faq["general"].([]interface{})) != 1 {
+		t.Fatalf("Expecting faq/general.json nested under faq, got %#v", data["faq"])
+	}
+
+	if _, ok := data["notes"]; ok == true {
+		t.Fatalf("Expecting files that are not YAML or JSON to be ignored.")
+	}
+}
+
+func TestLoadDataMalformed(t *testing.T) {
+	root := fixture(t, map[string]string{
+		"_data/team.yaml": "- name: Ana\n  role: [Editor\n",
+	})
+
+	_, err := LoadData(root)
+
+	if err == nil || strings.Contains(err.Error(), "team.yaml") == false {
+		t.Fatalf("Expecting an error naming the broken file, got %v", err)
+	}
+}
+
+func TestLoadDataMissing(t *testing.T) {
+	data, err := LoadData(fixture(t, map[string]string{"index.md": "# Home"}))
+	if err != nil || len(data) != 0 {
+		t.Fatalf("Expecting no data and no error, got %v, %v", data, err)
+	}
+}
+
+func TestSetDataWhileBuilding(t *testing.T) {
+	b := testBuilder(t, map[string]string{"index.md": "# Home"})
+
+	done := make(chan bool)
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			b.SetData(map[string]interface{}{"version": i})
+		}
+		done <- true
+	}()
+
+	for i := 0; i < 100; i++ {
+		b.NewPage(b.Root + PS + "index.md")
+	}
+
+	<-done
+
+	if p := b.NewPage(b.Root + PS + "index.md"); reflect.DeepEqual(p.Data, map[string]interface{}{"version": 99}) == false {
+		t.Fatalf("Expecting the last data set, got %v", p.Data)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"regexp"
+)
+
+var emojiPattern = regexp.MustCompile(`:([a-z0-9_+\-]+):`)
+
+// Shortcodes known by expandEmoji.
+var emojiCodes = map[string]string{
+	"+1":                 "\U0001F44D",
+	"-1":                 "\U0001F44E",
+	"bangbang":           "‼️",
+	"beer":               "\U0001F37A",
+	"bell":               "\U0001F514",
+	"book":               "\U0001F4D6",
+	"bookmark":           "\U0001F516",
+	"bug":                "\U0001F41B",
+	"bulb":               "\U0001F4A1",
+	"calendar":           "\U0001F4C6",
+	"check":              "✔️",
+	"clap":               "\U0001F44F",
+	"coffee":             "☕",
+	"construction":       "\U0001F6A7",
+	"cry":                "\U0001F622",
+	"email":              "\U0001F4E7",
+	"eyes":               "\U0001F440",
+	"fire":               "\U0001F525",
+	"gear":               "⚙️",
+	"heart":              "❤️",
+	"heavy_check_mark":   "✔️",
+	"hourglass":          "⌛",
+	"information_source": "ℹ️",
+	"key":                "\U0001F511",
+	"laughing":           "\U0001F606",
+	"link":               "\U0001F517",
+	"lock":               "\U0001F512",
+	"mag":                "\U0001F50D",
+	"memo":               "\U0001F4DD",
+	"no_entry":           "⛔",
+	"ok_hand":            "\U0001F44C",
+	"package":            "\U0001F4E6",
+	"pencil":             "\U0001F4DD",
+	"point_right":        "\U0001F449",
+	"pushpin":            "\U0001F4CC",
+	"question":           "❓",
+	"rocket":             "\U0001F680",
+	"scream":             "\U0001F631",
+	"smile":              "\U0001F604",
+	"sparkles":           "✨",
+	"star":               "⭐",
+	"tada":               "\U0001F389",
+	"thinking":           "\U0001F914",
+	"thumbsdown":         "\U0001F44E",
+	"thumbsup":           "\U0001F44D",
+	"warning":            "⚠️",
+	"wink":               "\U0001F609",
+	"wrench":             "\U0001F527",
+	"x":                  "❌",
+	"zap":                "⚡",
+}
+
+// Replaces :shortcode: tokens with their emoji, unknown codes are left as
+// they are.
+func expandEmoji(s string) string {
+	return emojiPattern.ReplaceAllStringFunc(s, func(token string) string {
+		if emoji, ok := emojiCodes[token[1:len(token)-1]]; ok {
+			return emoji
+		}
+		return token
+	})
+}
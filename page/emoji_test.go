@@ -0,0 +1,52 @@
+package page
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmojiReplace(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"launch.md": "# Launch :rocket:\n\nWe did it :tada: :notanemoji:\n",
+		"code.md":   "Run `echo :tada:` first.\n\n    :rocket:\n\n<a title=\":tada:\" href=\"/x\">go :zap:</a>\n",
+	})
+
+	b.EmojiReplace = true
+
+	p, err := b.Build(filepath.Join(b.Root, "launch.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Title != "Launch \U0001F680" {
+		t.Fatalf("Expecting the emoji on the title, got %q", p.Title)
+	}
+
+	if strings.Contains(string(p.Content), "We did it \U0001F389") == false {
+		t.Fatalf("Expecting the emoji on the content, got %q", p.Content)
+	}
+
+	if strings.Contains(string(p.Content), ":notanemoji:") == false {
+		t.Fatalf("Expecting unknown codes to be left verbatim, got %q", p.Content)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "code.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"<code>echo :tada:</code>", "<pre><code>:rocket:", `title=":tada:"`, "go \u26a1"} {
+		if strings.Contains(string(p.Content), expected) == false {
+			t.Fatalf("Expecting %q on the content, got %q", expected, p.Content)
+		}
+	}
+
+	b.EmojiReplace = false
+
+	p, _ = b.Build(filepath.Join(b.Root, "launch.md"))
+
+	if p.Title != "Launch :rocket:" {
+		t.Fatalf("Expecting no replacement when disabled, got %q", p.Title)
+	}
+}
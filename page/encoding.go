@@ -0,0 +1,76 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Characters of windows-1252 between 0x80 and 0x9f, where it differs from
+// ISO-8859-1. Bytes left out are the same code points in both.
+var windows1252 = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„',
+	0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ',
+	0x89: '‰', 0x8a: 'Š', 0x8b: '‹', 0x8c: 'Œ',
+	0x8e: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“',
+	0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9a: 'š', 0x9b: '›',
+	0x9c: 'œ', 0x9e: 'ž', 0x9f: 'Ÿ',
+}
+
+// Returns the given source as UTF-8. Sources that are valid UTF-8 already are
+// returned as they are, others are transcoded from encoding (one of
+// "iso-8859-1", also "latin1", or "windows-1252").
+func toUTF8(buf []byte, encoding string) ([]byte, error) {
+	if utf8.Valid(buf) {
+		return buf, nil
+	}
+
+	var table map[byte]rune
+
+	switch strings.ToLower(encoding) {
+	case "":
+		return nil, errors.New("it is not valid UTF-8 and no source encoding is set")
+	case "iso-8859-1", "latin1", "latin-1":
+	case "windows-1252", "cp1252":
+		table = windows1252
+	default:
+		return nil, fmt.Errorf("unknown source encoding %q", encoding)
+	}
+
+	out := make([]rune, 0, len(buf))
+
+	for _, c := range buf {
+		if r, ok := table[c]; ok {
+			out = append(out, r)
+		} else {
+			out = append(out, rune(c))
+		}
+	}
+
+	return []byte(string(out)), nil
+}
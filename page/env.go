@@ -0,0 +1,103 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// An env block delimiter, i.e: {{% env "staging" "dev" %}} or {{% /env %}}.
+var envPattern = regexp.MustCompile(`\{\{%\s*(/?)env((?:\s+"[^"]*")*)\s*%\}\}`)
+
+var envNamePattern = regexp.MustCompile(`"([^"]*)"`)
+
+// Tells whether an env block opened with the given names is rendered in the
+// env environment.
+func envMatches(names []byte, env string) bool {
+	for _, name := range envNamePattern.FindAllSubmatch(names, -1) {
+		if string(name[1]) == env {
+			return true
+		}
+	}
+	return false
+}
+
+// Removes the env blocks of src that are not meant for the env environment,
+// along with the delimiters of those that are. Blocks may be nested, a block
+// is only rendered if the blocks around it are too. Closing delimiters
+// without a block are left alone, unclosed blocks run until the end.
+// Delimiters within code (see markdownCode) are left alone too.
+func expandEnvBlocks(src []byte, env string, code [][]int) []byte {
+	matches := envPattern.FindAllSubmatchIndex(src, -1)
+
+	if len(matches) == 0 {
+		return src
+	}
+
+	var out bytes.Buffer
+
+	// Whether each of the open blocks is rendered.
+	open := []bool{}
+	hidden := 0
+	offset := 0
+
+	for _, m := range matches {
+		if inCode(code, m[0]) {
+			continue
+		}
+
+		if hidden == 0 {
+			out.Write(src[offset:m[0]])
+		}
+		offset = m[1]
+
+		if m[3] > m[2] {
+			// Closing delimiter.
+			if len(open) == 0 {
+				if hidden == 0 {
+					out.Write(src[m[0]:m[1]])
+				}
+				continue
+			}
+			if open[len(open)-1] == false {
+				hidden--
+			}
+			open = open[:len(open)-1]
+			continue
+		}
+
+		keep := envMatches(src[m[4]:m[5]], env)
+		if keep == false {
+			hidden++
+		}
+		open = append(open, keep)
+	}
+
+	if hidden == 0 {
+		out.Write(src[offset:])
+	}
+
+	return out.Bytes()
+}
@@ -0,0 +1,81 @@
+package page
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvBlocks(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"notes.md": "# Notes\n\nPublic.\n\n{{% env \"staging\" %}}\nInternal note.\n\n{{% env \"dev\" %}}\nDev only.\n{{% /env %}}\n{{% /env %}}\n\nFooter.\n",
+	})
+
+	build := func(env string) string {
+		b.Environment = env
+		p, err := b.Build(filepath.Join(b.Root, "notes.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(p.Content)
+	}
+
+	content := build("staging")
+
+	if strings.Contains(content, "Internal note.") == false || strings.Contains(content, "Footer.") == false {
+		t.Fatalf("Expecting the staging block to be kept, got %q", content)
+	}
+	if strings.Contains(content, "Dev only.") || strings.Contains(content, "env") {
+		t.Fatalf("Expecting the nested dev block and the delimiters to be dropped, got %q", content)
+	}
+
+	content = build("")
+
+	if strings.Contains(content, "Internal note.") || strings.Contains(content, "Dev only.") {
+		t.Fatalf("Expecting the staging block to be dropped, got %q", content)
+	}
+	if strings.Contains(content, "Public.") == false || strings.Contains(content, "Footer.") == false {
+		t.Fatalf("Expecting the content around the block to be kept, got %q", content)
+	}
+
+	// The dev block is within the staging one.
+	if content = build("dev"); strings.Contains(content, "Dev only.") {
+		t.Fatalf("Expecting nested blocks to be dropped with their parent, got %q", content)
+	}
+}
+
+func TestEnvBlocksInCode(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"syntax.md": "# Syntax\n\nWrap it in `{{% env \"dev\" %}}`, like:\n\n" +
+			"```\n{{% env \"dev\" %}}\nDev only.\n{{% /env %}}\n```\n\nFooter.\n",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "syntax.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := string(p.Content)
+
+	if strings.Count(content, "{{% env") != 2 || strings.Contains(content, "{{% /env %}}") == false {
+		t.Fatalf("Expecting the delimiters in code to be left alone, got %q", content)
+	}
+	if strings.Contains(content, "Dev only.") == false || strings.Contains(content, "Footer.") == false {
+		t.Fatalf("Expecting the code to be kept, got %q", content)
+	}
+}
+
+func TestExpandEnvBlocks(t *testing.T) {
+	tests := map[string]string{
+		`a{{% env "x" "y" %}}b{{% /env %}}c`: "abc",
+		`a{{% env "z" %}}b{{% /env %}}c`:     "ac",
+		`a{{% /env %}}c`:                     `a{{% /env %}}c`,
+		`a{{% env "z" %}}b`:                  "a",
+	}
+
+	for src, expected := range tests {
+		if out := string(expandEnvBlocks([]byte(src), "y", nil)); out != expected {
+			t.Fatalf("Expecting %q for %q, got %q", expected, src, out)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"crypto/sha1"
+	"fmt"
+)
+
+// Returns an entity tag for the page, a hash of everything the page is
+// rendered from: its content, title, metadata, menus and the builder's
+// TemplateVersion. It does not change for as long as those do not.
+func (p *Page) etag() string {
+	h := sha1.New()
+
+	version := ""
+	if p.builder != nil {
+		version = p.builder.TemplateVersion
+	}
+
+	// fmt prints maps sorted by key, so the output is stable across runs.
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00",
+		version, p.Link, p.Title, p.Description, p.Content, p.Lead)
+	fmt.Fprintf(h, "%s\x00%s\x00", p.ContentHeader, p.ContentFooter)
+	fmt.Fprintf(h, "%v\x00%v\x00%v\x00%v\x00", p.Meta, p.Menu, p.SideMenu, p.BreadCrumb)
+	fmt.Fprintf(h, "%v\x00%v\x00%v\x00", p.Styles, p.Scripts, p.Data)
+
+	return fmt.Sprintf(`"%x"`, h.Sum(nil))
+}
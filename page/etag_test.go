@@ -0,0 +1,53 @@
+package page
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md": "# Home\n\nHello.",
+	})
+
+	file := filepath.Join(b.Root, "index.md")
+
+	first, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.ETag == "" || first.ETag != second.ETag {
+		t.Fatalf("Expecting identical ETags for identical content, got %s and %s", first.ETag, second.ETag)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("# Home\n\nGoodbye."), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if changed.ETag == first.ETag {
+		t.Fatalf("Expecting the ETag to change along with the content.")
+	}
+
+	b.TemplateVersion = "index.tpl:1234"
+
+	retemplated, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if retemplated.ETag == changed.ETag {
+		t.Fatalf("Expecting the ETag to change along with the templates.")
+	}
+}
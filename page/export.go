@@ -0,0 +1,116 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Returns the file, relative to an export's directory, the page served at url
+// is written to (i.e: "/guide/intro" becomes "guide/intro/index.html").
+func exportFile(url string) string {
+	if strings.HasSuffix(url, "/") {
+		return strings.TrimLeft(url, "/") + "index.html"
+	}
+	if path.Ext(url) != "" {
+		return strings.TrimLeft(url, "/")
+	}
+	return strings.TrimLeft(url, "/") + "/index.html"
+}
+
+// Writes an exported file, creating the directories it's in, along with a
+// gzip-compressed copy named file + ".gz" if CompressOutput is set.
+func (b *Builder) writeOutput(file string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(file, data, 0644); err != nil {
+		return err
+	}
+
+	if b.CompressOutput == false {
+		return nil
+	}
+
+	var buf bytes.Buffer
+
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file+".gz", buf.Bytes(), 0644)
+}
+
+// Renders every published page with tpl into dir, at the path of its URL (see
+// exportFile), for serving the site as static files. Files other than pages
+// are not copied. Pages with Funcs of their own are rendered with a clone of
+// tpl, which must not have been executed then.
+func (b *Builder) Export(dir string, tpl *template.Template) error {
+	var shared *template.Template
+
+	return b.walkPublished(b.Root, func(file string, info os.FileInfo, meta map[string]interface{}, url string) error {
+		p, err := b.Build(file)
+
+		if err != nil {
+			return fmt.Errorf("Could not export %s: %s", url, err.Error())
+		}
+
+		var buf bytes.Buffer
+
+		if len(p.Funcs()) > 0 {
+			err = p.Execute(&buf, tpl)
+		} else {
+			if shared == nil {
+				if shared, err = tpl.Clone(); err != nil {
+					return err
+				}
+			}
+			err = shared.Execute(&buf, p)
+		}
+
+		if err != nil {
+			return fmt.Errorf("Could not export %s: %s", url, err.Error())
+		}
+
+		return b.writeOutput(filepath.Join(dir, filepath.FromSlash(exportFile(url))), buf.Bytes())
+	})
+}
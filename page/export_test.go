@@ -0,0 +1,63 @@
+package page
+
+import (
+	"bytes"
+	"compress/gzip"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportCompressed(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":       "# Home",
+		"guide/intro.md": "# Intro",
+	})
+
+	tpl := template.Must(template.New("index.tpl").Parse(`<title>{{.Title}}</title>{{.Content}}`))
+
+	dir := t.TempDir()
+
+	if err := b.Export(dir, tpl); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "index.html.gz")); err == nil {
+		t.Fatalf("Expecting no compressed copies unless CompressOutput is set.")
+	}
+
+	b.CompressOutput = true
+
+	if err := b.Export(dir, tpl); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []string{"index.html", "guide/intro/index.html"} {
+		html, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fp, err := os.Open(filepath.Join(dir, filepath.FromSlash(file)) + ".gz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer fp.Close()
+
+		zr, err := gzip.NewReader(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		unzipped, err := ioutil.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Equal(html, unzipped) == false || bytes.Contains(html, []byte("<title>")) == false {
+			t.Fatalf("Expecting %s.gz to decompress to %q, got %q", file, html, unzipped)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"errors"
+	"html/template"
+)
+
+// Returned when a URL path resolves to no page.
+var ErrNotFound = errors.New("Page not found.")
+
+// Returns just the rendered content of the page at urlPath (i.e:
+// "/guide/intro"), with no header, footer or menus, for pages that are loaded
+// piece by piece. Returns ErrNotFound if there is no such published page.
+func (b *Builder) RenderFragment(urlPath string) (template.HTML, error) {
+	file, transform := b.Resolve(b.Root + urlPath)
+
+	if transform != MARKDOWN_TRANSFORM {
+		return "", ErrNotFound
+	}
+
+	if err := b.checkSize(b.source(file)); err != nil {
+		return "", err
+	}
+
+	_, src, err := b.readSource(b.source(file))
+
+	if err != nil {
+		return "", err
+	}
+
+	content, _ := b.NewPage(file).renderContent(src)
+
+	return content, nil
+}
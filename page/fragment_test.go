@@ -0,0 +1,36 @@
+package page
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderFragment(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/_header.md": "Header",
+		"guide/intro.md":   "---\ntitle: Intro\n---\n# Intro\n\nSee [setup](setup.md).\n",
+		"guide/setup.md":   "# Setup",
+	})
+
+	fragment, err := b.RenderFragment("/guide/intro")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := b.Build(b.Root + PS + "guide/intro.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fragment != p.Content {
+		t.Fatalf("Expecting the page's content %q, got %q", p.Content, fragment)
+	}
+
+	if strings.Contains(string(fragment), "Header") {
+		t.Fatalf("Expecting no header, got %q", fragment)
+	}
+
+	if _, err := b.RenderFragment("/guide/missing"); err != ErrNotFound {
+		t.Fatalf("Expecting ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,424 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var frontMatterDelimiter = []byte("---")
+
+// Layouts accepted for dates in front matter.
+var dateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	time.RFC3339,
+}
+
+// Something wrong found while checking the content of a site.
+type Problem struct {
+	// File the problem was found in, relative to the content root.
+	File string
+	// Front matter key, if the problem is about one.
+	Key string
+	// Description of the problem.
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Key != "" {
+		return fmt.Sprintf("%s: %s: %s", p.File, p.Key, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.File, p.Message)
+}
+
+// Splits the YAML front matter (delimited by "---" lines at the very beginning
+// of the file) from the rest of the source.
+func splitFrontMatter(buf []byte) (map[string]interface{}, []byte, error) {
+	meta := map[string]interface{}{}
+
+	if bytes.HasPrefix(buf, frontMatterDelimiter) == false {
+		return meta, buf, nil
+	}
+
+	lines := bytes.SplitAfter(buf, []byte("\n"))
+
+	if len(lines) < 2 || len(bytes.TrimSpace(lines[0])) != len(frontMatterDelimiter) {
+		return meta, buf, nil
+	}
+
+	offset := len(lines[0])
+
+	for _, line := range lines[1:] {
+		if bytes.Equal(bytes.TrimSpace(line), frontMatterDelimiter) {
+			err := yaml.Unmarshal(buf[len(lines[0]):offset], &meta)
+			if err != nil {
+				return nil, nil, err
+			}
+			return meta, buf[offset+len(line):], nil
+		}
+		offset += len(line)
+	}
+
+	return nil, nil, fmt.Errorf("Missing closing %q.", frontMatterDelimiter)
+}
+
+// Returns the front matter with the FrontMatterProfiles named by its
+// "_extends" key (a name or a list of names, later ones win) merged in, keys
+// set on the page itself win over those of its profiles.
+func (b *Builder) extendMeta(meta map[string]interface{}) (map[string]interface{}, error) {
+	names := metaStrings(meta, "_extends")
+
+	if len(names) == 0 {
+		return meta, nil
+	}
+
+	extended := map[string]interface{}{}
+
+	for _, name := range names {
+		profile, ok := b.FrontMatterProfiles[name]
+		if ok == false {
+			return nil, fmt.Errorf("There is no %q front matter profile.", name)
+		}
+		for key, value := range profile {
+			// Copied, or pages would share (and could change) the
+			// profile's nested values.
+			extended[key] = copyValue(value)
+		}
+	}
+
+	for key, value := range meta {
+		if key != "_extends" {
+			extended[key] = value
+		}
+	}
+
+	return extended, nil
+}
+
+// Returns only the AllowedMetaKeys of the given front matter, or all of it if
+// there are none.
+func (b *Builder) allowedMeta(meta map[string]interface{}) map[string]interface{} {
+	if b == nil || len(b.AllowedMetaKeys) == 0 || meta == nil {
+		return meta
+	}
+
+	allowed := map[string]interface{}{}
+
+	for _, key := range b.AllowedMetaKeys {
+		if value, ok := meta[key]; ok {
+			allowed[key] = value
+		}
+	}
+
+	return allowed
+}
+
+// Returns a front matter value as a string, or "" if it's not set.
+func metaString(meta map[string]interface{}, key string) string {
+	value, ok := meta[key]
+	if ok == false || value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// Returns a front matter list as strings, a single value is taken as a list
+// of one.
+func metaStrings(meta map[string]interface{}, key string) []string {
+	list := []string{}
+	switch value := meta[key].(type) {
+	case []interface{}:
+		for _, item := range value {
+			if item != nil {
+				list = append(list, fmt.Sprintf("%v", item))
+			}
+		}
+	case nil:
+	default:
+		list = append(list, fmt.Sprintf("%v", value))
+	}
+	return list
+}
+
+// Tells whether the front matter marks the page as a draft.
+func isDraft(meta map[string]interface{}) bool {
+	draft, _ := meta["draft"].(bool)
+	return draft
+}
+
+// Returns the current time, according to the builder's clock.
+func (b *Builder) now() time.Time {
+	if b.Now != nil {
+		return b.Now()
+	}
+	return time.Now()
+}
+
+// Tells whether a page with the given front matter may be listed and served:
+// drafts, pages dated in the future and pages past their "expires" date are
+// hidden unless ShowDrafts is set.
+func (b *Builder) isPublished(meta map[string]interface{}) bool {
+	if b.ShowDrafts {
+		return true
+	}
+	if isDraft(meta) {
+		return false
+	}
+	if date, ok := parseDate(meta["date"]); ok && date.After(b.now()) {
+		return false
+	}
+	if isExpired(meta, b.now()) {
+		return false
+	}
+	return true
+}
+
+// Tells whether the "expires" date of the front matter, if any, is past.
+func isExpired(meta map[string]interface{}, now time.Time) bool {
+	expires, ok := parseDate(meta["expires"])
+	return ok && now.After(expires)
+}
+
+// Returns the earliest "date" or "expires" date of the front matter still to
+// come, when the publishing of its page changes, zero if there's none.
+func nextChange(meta map[string]interface{}, now time.Time) time.Time {
+	next := time.Time{}
+	for _, key := range []string{"date", "expires"} {
+		if date, ok := parseDate(meta[key]); ok && date.After(now) {
+			if next.IsZero() || date.Before(next) {
+				next = date
+			}
+		}
+	}
+	return next
+}
+
+// Returns the earliest time the publishing of a page of directory changes
+// (see nextChange), zero if none will. Whatever lists the directory's
+// published pages is only valid until then.
+func (b *Builder) upcoming(directory string) time.Time {
+	next := time.Time{}
+
+	if b.ShowDrafts {
+		return next
+	}
+
+	now := b.now()
+
+	for _, file := range b.filterList(directory, b.pageFilter) {
+		date := nextChange(b.metaOf(b.source(directory+PS+file.Name())), now)
+		if date.IsZero() == false && (next.IsZero() || date.Before(next)) {
+			next = date
+		}
+	}
+
+	return next
+}
+
+// Like isPublished, for a file. Files that can't be read are left for Build to
+// complain about.
+func (b *Builder) isPublishedFile(file string) bool {
+	meta, err := b.readMeta(file)
+	if err != nil {
+		return true
+	}
+	return b.isPublished(meta)
+}
+
+// Tells whether a file requested by its exact name (i.e: "post.md") may be
+// served as it is: pages that are drafts, scheduled or expired may not, as
+// Resolve doesn't resolve them either. Other files are not read.
+func (b *Builder) Servable(file string) bool {
+	if name := path.Base(file); b.pageName(name) == name {
+		return true
+	}
+	return b.isPublishedFile(b.source(file))
+}
+
+// Returns the front matter of a file, nil if it can't be read.
+func (b *Builder) metaOf(file string) map[string]interface{} {
+	meta, err := b.readMeta(file)
+	if err != nil {
+		return nil
+	}
+	return meta
+}
+
+// Returns the integer value of a front matter key, 0 if it's not set or not a
+// number.
+func metaInt(meta map[string]interface{}, key string) int {
+	switch v := meta[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		i, _ := strconv.Atoi(strings.TrimSpace(v))
+		return i
+	}
+	return 0
+}
+
+// Returns a front matter value as a date.
+func parseDate(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range dateLayouts {
+			t, err := time.Parse(layout, strings.TrimSpace(v))
+			if err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Layout dates are formatted with when the builder has no DateFormat.
+const defaultDateFormat = "January 2, 2006"
+
+// Copies the front matter date, if any, into a menu or listing item as "date"
+// (a time.Time) and "date_formatted" (formatted with DateFormat, in
+// DateLocale).
+func (b *Builder) applyDate(item map[string]interface{}, meta map[string]interface{}) {
+	date, ok := parseDate(meta["date"])
+
+	if ok == false {
+		return
+	}
+
+	layout, locale := defaultDateFormat, ""
+	if b != nil {
+		if b.DateFormat != "" {
+			layout = b.DateFormat
+		}
+		locale = b.DateLocale
+	}
+
+	item["date"] = date
+	item["date_formatted"] = formatDate(date, layout, locale)
+}
+
+// Tells whether a front matter value is of the given schema type (one of
+// "string", "int", "bool", "date" or "list").
+func hasType(value interface{}, kind string) bool {
+	switch kind {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "int":
+		switch v := value.(type) {
+		case int, int64:
+			return true
+		case string:
+			_, err := strconv.Atoi(v)
+			return err == nil
+		}
+		return false
+	case "bool":
+		_, ok := value.(bool)
+		return ok
+	case "date":
+		_, ok := parseDate(value)
+		return ok
+	case "list":
+		_, ok := value.([]interface{})
+		return ok
+	}
+	return true
+}
+
+// Returns the problems of a page's front matter: required keys it lacks, and
+// values that don't match the type given in FrontMatterSchema.
+func (b *Builder) metaProblems(rel string, meta map[string]interface{}, required []string) []Problem {
+	problems := []Problem{}
+
+	for _, key := range required {
+		if _, ok := meta[key]; ok == false {
+			problems = append(problems, Problem{File: rel, Key: key, Message: "missing required key"})
+		}
+	}
+
+	keys := []string{}
+	for key, _ := range b.FrontMatterSchema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, ok := meta[key]
+		if ok && hasType(value, b.FrontMatterSchema[key]) == false {
+			problems = append(problems, Problem{File: rel, Key: key, Message: fmt.Sprintf("expecting a %s", b.FrontMatterSchema[key])})
+		}
+	}
+
+	return problems
+}
+
+// Reports pages under root whose front matter lacks any of the required keys,
+// has values that don't match the type given in FrontMatterSchema or a slug
+// they can't be served at (see SLUG_COLLISION_ERROR).
+func (b *Builder) ValidateFrontMatter(root string, required []string) ([]Problem, error) {
+	problems := []Problem{}
+
+	err := b.walkPages(root, func(file string, info os.FileInfo) error {
+		meta, err := b.readMeta(b.source(file))
+
+		rel, _ := filepath.Rel(root, file)
+		rel = filepath.ToSlash(rel)
+
+		if err != nil {
+			problems = append(problems, Problem{File: rel, Message: err.Error()})
+			return nil
+		}
+
+		problems = append(problems, b.metaProblems(rel, meta, required)...)
+
+		if _, err := b.servedName(filepath.Dir(file), filepath.Base(file), meta); err != nil {
+			problems = append(problems, Problem{File: rel, Key: "slug", Message: err.Error()})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,427 @@
+package page
+
+import (
+	"html/template"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateFrontMatter(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"posts/complete.md": "---\ntitle: Complete\ndate: 2013-04-01\n---\n# Complete\n",
+		"posts/missing.md":  "---\ntitle: Missing date\n---\n# Missing date\n",
+		"posts/baddate.md":  "---\ntitle: Bad date\ndate: yesterday\n---\n",
+		"_drafts/draft.md":  "No front matter at all.",
+	})
+
+	b.FrontMatterSchema = map[string]string{"date": "date"}
+
+	problems, err := b.ValidateFrontMatter(b.Root, []string{"title", "date"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(problems) != 2 {
+		t.Fatalf("Expecting two problems, got %v", problems)
+	}
+
+	if problems[0].File != "posts/baddate.md" || problems[0].Key != "date" {
+		t.Fatalf("Expecting a type problem for posts/baddate.md, got %v", problems[0])
+	}
+
+	if problems[1].File != "posts/missing.md" || problems[1].Key != "date" {
+		t.Fatalf("Expecting a missing date for posts/missing.md, got %v", problems[1])
+	}
+
+	clean := testBuilder(t, map[string]string{
+		"posts/complete.md": "---\ntitle: Complete\ndate: 2013-04-01\n---\n# Complete\n",
+	})
+
+	problems, err = clean.ValidateFrontMatter(clean.Root, []string{"title", "date"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("Expecting no problems, got %v", problems)
+	}
+}
+
+func TestBuildFrontMatter(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"page.md": "---\ntitle: From front matter\n---\n# From heading\n",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "page.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Title != "From front matter" {
+		t.Fatalf("Expecting the front matter title, got %q", p.Title)
+	}
+
+	if string(p.Content) != "<h1>From heading</h1>\n" {
+		t.Fatalf("Expecting the front matter to be stripped, got %q", p.Content)
+	}
+}
+
+func TestScheduledPages(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"blog/index.md":  "# Blog",
+		"blog/future.md": "---\ndate: 2013-06-01\n---\n# Future\n",
+		"blog/past.md":   "---\ndate: 2013-04-01\n---\n# Past\n",
+	})
+
+	b.Now = func() time.Time { return time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC) }
+
+	sideMenu := func() []string {
+		p, err := b.Build(filepath.Join(b.Root, "blog", "index.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		links := []string{}
+		for _, item := range p.SideMenu {
+			links = append(links, item["link"].(string))
+		}
+		return links
+	}
+
+	if links := sideMenu(); reflect.DeepEqual(links, []string{"/blog/past"}) == false {
+		t.Fatalf("Expecting only the past post, got %v", links)
+	}
+
+	if _, transform := b.Resolve(b.Root + "/blog/future"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting the future post not to be served.")
+	}
+
+	if _, transform := b.Resolve(b.Root + "/blog/past"); transform != MARKDOWN_TRANSFORM {
+		t.Fatalf("Expecting the past post to be served.")
+	}
+
+	if b.Servable(b.Root+"/blog/future.md") || b.Servable(b.Root+"/blog/past.md") == false {
+		t.Fatalf("Expecting only the source of the past post to be served.")
+	}
+
+	b.ShowDrafts = true
+
+	if links := sideMenu(); reflect.DeepEqual(links, []string{"/blog/future", "/blog/past"}) == false {
+		t.Fatalf("Expecting both posts in preview mode, got %v", links)
+	}
+
+	if _, transform := b.Resolve(b.Root + "/blog/future"); transform != MARKDOWN_TRANSFORM {
+		t.Fatalf("Expecting the future post to be served in preview mode.")
+	}
+
+	if b.Servable(b.Root+"/blog/future.md") == false {
+		t.Fatalf("Expecting the source of the future post to be served in preview mode.")
+	}
+}
+
+func TestExpiredPages(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"news/index.md":   "# News",
+		"news/expired.md": "---\nexpires: 2013-04-30\n---\n# Expired\n",
+		"news/current.md": "---\nexpires: 2013-05-02 12:00\n---\n# Current\n",
+		"news/old.html":   "---\nkeep_extension: true\nexpires: 2013-04-30\n---\n<h1>Old</h1>",
+		"news/sale.txt":   "---\nexpires: 2013-04-30\n---\nSale!\n",
+	})
+
+	b.Now = func() time.Time { return time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC) }
+
+	sideMenu := func() []string {
+		p, err := b.Build(filepath.Join(b.Root, "news", "index.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		links := []string{}
+		for _, item := range p.SideMenu {
+			links = append(links, item["link"].(string))
+		}
+		return links
+	}
+
+	if links := sideMenu(); reflect.DeepEqual(links, []string{"/news/current"}) == false {
+		t.Fatalf("Expecting only the current announcement, got %v", links)
+	}
+
+	if _, transform := b.Resolve(b.Root + "/news/expired"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting the expired announcement not to be served.")
+	}
+
+	if _, transform := b.Resolve(b.Root + "/news/current"); transform != MARKDOWN_TRANSFORM {
+		t.Fatalf("Expecting the current announcement to be served.")
+	}
+
+	// Nor are expired files served as they are.
+	b.UnknownExtensions = UNKNOWN_EXTENSIONS_RAW
+
+	for _, file := range []string{"expired.md", "old.html"} {
+		if b.Servable(b.Root + "/news/" + file) {
+			t.Fatalf("Expecting the source of %s not to be served.", file)
+		}
+		if _, transform := b.Resolve(b.Root + "/news/" + file); transform != NO_TRANSFORM {
+			t.Fatalf("Expecting %s not to be found, got %d", file, transform)
+		}
+	}
+
+	if _, transform := b.Resolve(b.Root + "/news/sale"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting the expired raw file not to be served, got %d", transform)
+	}
+
+	if b.Servable(b.Root+"/news/current.md") == false {
+		t.Fatalf("Expecting the source of the current announcement to be served.")
+	}
+
+	listing, err := b.BuildListing("news", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listing) != 1 || listing[0]["link"] != "/news/current" {
+		t.Fatalf("Expecting the expired announcement out of the listing, got %v", listing)
+	}
+
+	b.ShowDrafts = true
+
+	if links := sideMenu(); reflect.DeepEqual(links, []string{"/news/current", "/news/expired", "/news/old.html"}) == false {
+		t.Fatalf("Expecting every announcement in preview mode, got %v", links)
+	}
+
+	if _, transform := b.Resolve(b.Root + "/news/expired"); transform != MARKDOWN_TRANSFORM {
+		t.Fatalf("Expecting the expired announcement to be served in preview mode.")
+	}
+
+	if _, transform := b.Resolve(b.Root + "/news/sale"); transform != RAW_TRANSFORM {
+		t.Fatalf("Expecting the expired raw file to be served in preview mode, got %d", transform)
+	}
+}
+
+func TestFrontMatterProfiles(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"post.md":    "---\n_extends: article\ntitle: A post\ndescription: Mine\n---\nText.\n",
+		"missing.md": "---\n_extends: nope\n---\nText.\n",
+	})
+
+	b.FrontMatterProfiles = map[string]map[string]interface{}{
+		"article": {"description": "An article", "og_type": "article", "author": "Staff"},
+	}
+
+	p, err := b.Build(filepath.Join(b.Root, "post.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Meta["author"] != "Staff" || p.Meta["og_type"] != "article" {
+		t.Fatalf("Expecting the profile's keys, got %v", p.Meta)
+	}
+
+	if p.Description != "Mine" || p.Title != "A post" {
+		t.Fatalf("Expecting the page to override the profile, got %q and %q", p.Description, p.Title)
+	}
+
+	if _, ok := p.Meta["_extends"]; ok {
+		t.Fatalf("Expecting _extends to be left out, got %v", p.Meta)
+	}
+
+	if _, err := b.Build(filepath.Join(b.Root, "missing.md")); err == nil || strings.Contains(err.Error(), "nope") == false {
+		t.Fatalf("Expecting an error naming the missing profile, got %v", err)
+	}
+}
+
+func TestRawFrontMatter(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"post.md": "---\n_extends: article\ntitle: A post\ntags: [go, docs]\nauthor:\n  name: Jane\n  links:\n    - http://example.org\n---\nText.\n",
+	})
+
+	b.FrontMatterProfiles = map[string]map[string]interface{}{
+		"article": {"og_type": "article"},
+	}
+
+	p, err := b.Build(filepath.Join(b.Root, "post.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"_extends": "article",
+		"title":    "A post",
+		"tags":     []interface{}{"go", "docs"},
+		"author": map[interface{}]interface{}{
+			"name":  "Jane",
+			"links": []interface{}{"http://example.org"},
+		},
+	}
+
+	if reflect.DeepEqual(p.FrontMatter, expected) == false {
+		t.Fatalf("Expecting the front matter verbatim, got %#v", p.FrontMatter)
+	}
+
+	if p.Meta["og_type"] != "article" {
+		t.Fatalf("Expecting Meta to have the profile merged in, got %v", p.Meta)
+	}
+
+	// Changing nested values of Meta leaves FrontMatter as it is.
+	p.Meta["author"].(map[interface{}]interface{})["name"] = "John"
+
+	if reflect.DeepEqual(p.FrontMatter, expected) == false {
+		t.Fatalf("Expecting FrontMatter not to share values with Meta, got %#v", p.FrontMatter)
+	}
+}
+
+func TestRawFrontMatterWithoutProfile(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"post.md": "---\ntitle: A post\ntags: [go, docs]\n---\nText.\n",
+	})
+
+	b.FrontMatterProfiles = map[string]map[string]interface{}{
+		"article": {"og_type": "article", "tags": []interface{}{"article"}},
+	}
+
+	p, err := b.Build(filepath.Join(b.Root, "post.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Meta["title"] = "Changed"
+	p.Meta["tags"].([]interface{})[0] = "changed"
+
+	expected := map[string]interface{}{
+		"title": "A post",
+		"tags":  []interface{}{"go", "docs"},
+	}
+
+	if reflect.DeepEqual(p.FrontMatter, expected) == false {
+		t.Fatalf("Expecting FrontMatter not to be Meta, got %#v", p.FrontMatter)
+	}
+
+	// Nor do pages share the values of their profiles.
+	meta, err := b.extendMeta(map[string]interface{}{"_extends": "article"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta["tags"].([]interface{})[0] = "changed"
+
+	if tags := b.FrontMatterProfiles["article"]["tags"].([]interface{}); tags[0] != "article" {
+		t.Fatalf("Expecting the profile to be left as it is, got %v", tags)
+	}
+}
+
+func TestAllowedMetaKeys(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"page.md": "---\ntitle: Page\nauthor: Jane\ninternal_notes: secret\nweight: 3\n---\n# Page\n",
+	})
+
+	keys := func() []string {
+		p, err := b.Build(filepath.Join(b.Root, "page.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reflect.DeepEqual(p.Meta, p.FrontMatter) == false {
+			t.Fatalf("Expecting the same keys on Meta and FrontMatter, got %v and %v", p.Meta, p.FrontMatter)
+		}
+		if p.Weight != 3 {
+			t.Fatalf("Expecting every key to be used while building, got weight %d", p.Weight)
+		}
+		out := []string{}
+		for key := range p.Meta {
+			out = append(out, key)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	if all := keys(); reflect.DeepEqual(all, []string{"author", "internal_notes", "title", "weight"}) == false {
+		t.Fatalf("Expecting every key without AllowedMetaKeys, got %v", all)
+	}
+
+	b.AllowedMetaKeys = []string{"title", "author", "missing"}
+
+	if allowed := keys(); reflect.DeepEqual(allowed, []string{"author", "title"}) == false {
+		t.Fatalf("Expecting only the allowed keys, got %v", allowed)
+	}
+}
+
+func TestFrontMatterProfilesEverywhere(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":         "# Home",
+		"wip/index.md":     "---\n_extends: unfinished\n---\n# WIP",
+		"wip/notes.md":     "---\n_extends: unfinished\ntags: [todo]\n---\n# Notes",
+		"private/index.md": "---\n_extends: hidden\n---\n# Private",
+		"guide/index.md":   "---\n_extends: chapter\n---\n# Guide",
+		"guide/intro.md":   "---\ntags: [todo]\n---\n# Intro",
+	})
+
+	b.FrontMatterProfiles = map[string]map[string]interface{}{
+		"unfinished": {"draft": true},
+		"hidden":     {"robots": "noindex"},
+		"chapter":    {"title": "The Guide"},
+	}
+
+	urls, err := b.AllURLs(b.Root)
+	if err != nil || reflect.DeepEqual(urls, []string{"/", "/guide/", "/guide/intro", "/private/"}) == false {
+		t.Fatalf("Expecting drafts from profiles to be left out, got %v (%v)", urls, err)
+	}
+
+	sitemap, err := b.BuildSitemap(b.Root, "http://example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(sitemap), "/private/") || strings.Contains(string(sitemap), "/wip/") {
+		t.Fatalf("Expecting noindex and draft pages from profiles to be left out, got %s", sitemap)
+	}
+
+	if tags, err := b.TagCounts(b.Root); err != nil || tags["todo"] != 1 {
+		t.Fatalf("Expecting only published pages to be counted, got %v (%v)", tags, err)
+	}
+
+	p, err := b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	texts := []interface{}{}
+	for _, item := range p.Menu {
+		texts = append(texts, item["text"])
+	}
+
+	if reflect.DeepEqual(texts, []interface{}{"The Guide", "Private", "Wip"}) == false {
+		t.Fatalf("Expecting the profile's title on index items, got %v", texts)
+	}
+}
+
+func TestAllowedMetaKeysPageMethods(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"draft.md": "---\ntitle: Draft\ndraft: true\nbaseurl: https://other.example.com/\nfuncs: [extra]\n---\n# Draft\n",
+	})
+
+	b.AllowedMetaKeys = []string{"title"}
+	b.FuncSets = map[string]template.FuncMap{
+		"extra": {"shout": strings.ToUpper},
+	}
+
+	p, err := b.Build(filepath.Join(b.Root, "draft.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := p.Meta["draft"]; ok || len(p.Meta) != 1 {
+		t.Fatalf("Expecting only the title on Meta, got %v", p.Meta)
+	}
+
+	if p.Visibility() != VISIBILITY_DRAFT || p.IsPublic(nil) {
+		t.Fatalf("Expecting the page to still be a draft, got %v", p.Visibility())
+	}
+
+	if canonical := p.Canonical("http://example.org"); canonical != "https://other.example.com/draft" {
+		t.Fatalf("Expecting the baseurl to still apply, got %s", canonical)
+	}
+
+	if _, ok := p.Funcs()["shout"]; ok == false {
+		t.Fatalf("Expecting the page's funcs to still apply, got %v", p.Funcs())
+	}
+}
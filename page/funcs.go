@@ -0,0 +1,87 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// Returns the functions of the FuncSets named by the "funcs" key of the
+// page's front matter (a name or a list of names, later sets win).
+func (p *Page) Funcs() template.FuncMap {
+	funcs := template.FuncMap{}
+
+	if p.builder == nil {
+		return funcs
+	}
+
+	for _, name := range metaStrings(p.fullMeta(), "funcs") {
+		set, ok := p.builder.FuncSets[name]
+		if ok == false {
+			Logger.Printf("%s asks for the %q template functions, there are none.\n", p.FilePath, name)
+			continue
+		}
+		for key, fn := range set {
+			funcs[key] = fn
+		}
+	}
+
+	return funcs
+}
+
+// Returns a function for every function of the FuncSets, for templates to be
+// parsed with, that fails when it's called on a page that didn't ask for it.
+func (b *Builder) FuncStubs() template.FuncMap {
+	stubs := template.FuncMap{}
+
+	for _, set := range b.FuncSets {
+		for key, _ := range set {
+			name := key
+			stubs[name] = func(args ...interface{}) (interface{}, error) {
+				return nil, fmt.Errorf("%s is not available on this page", name)
+			}
+		}
+	}
+
+	return stubs
+}
+
+// Executes a clone of tpl for the page, with the page's Funcs over those of
+// the template. The template itself is never executed, so it can be cloned
+// again (html/template can't clone executed templates).
+func (p *Page) Execute(w io.Writer, tpl *template.Template) error {
+	clone, err := tpl.Clone()
+
+	if err != nil {
+		return err
+	}
+
+	if funcs := p.Funcs(); len(funcs) > 0 {
+		clone.Funcs(funcs)
+	}
+
+	return clone.Execute(w, p)
+}
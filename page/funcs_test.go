@@ -0,0 +1,52 @@
+package page
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestPageFuncs(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"shop.md":  "---\nfuncs: money\nprice: 1999\n---\n# Shop",
+		"about.md": "# About",
+	})
+
+	b.FuncSets = map[string]template.FuncMap{
+		"money": template.FuncMap{
+			"cents": func(v int) string { return fmt.Sprintf("$%d.%02d", v/100, v%100) },
+		},
+	}
+
+	tpl := template.Must(template.New("index.tpl").Funcs(b.FuncStubs()).Parse(`{{.Title}}{{if .Meta.price}}: {{cents .Meta.price}}{{end}}`))
+
+	shop, err := b.Build(b.Root + PS + "shop.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := shop.Execute(&buf, tpl); err != nil || buf.String() != "Shop: $19.99" {
+		t.Fatalf("Expecting the page's function to be called, got %q (%v)", buf.String(), err)
+	}
+
+	about, err := b.Build(b.Root + PS + "about.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	about.Meta["price"] = 5
+
+	buf.Reset()
+
+	if err := about.Execute(&buf, tpl); err == nil || strings.Contains(err.Error(), "cents is not available on this page") == false {
+		t.Fatalf("Expecting the function not to be available, got %q (%v)", buf.String(), err)
+	}
+
+	if _, ok := about.Funcs()["cents"]; ok {
+		t.Fatalf("Expecting no functions on a page that asks for none.")
+	}
+}
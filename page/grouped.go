@@ -0,0 +1,127 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// A top-level directory and the pages under it, as listed by
+// BuildGroupedIndex.
+type Section struct {
+	// Section title, from its _section.yaml, the front matter of its index or
+	// its directory name.
+	Name string
+	// Link to the section's index.
+	Link string
+	// Pages under the section, in the same order as on menus (each item has
+	// the "link" and "text" keys).
+	Pages []map[string]interface{}
+}
+
+// Returns the pages under root (a directory within the content root) grouped
+// by the top-level directory they belong to, pages directly under root go to
+// a first "Home" section. Only the pages immediately within each section are
+// listed, unless GroupRecursive is set.
+func (b *Builder) BuildGroupedIndex(root string) ([]Section, error) {
+	sections := []Section{}
+
+	home, err := b.sectionPages(root, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(home.Pages) > 0 {
+		home.Name = b.fileTitle(b.relPath(root) + "/index")
+		sections = append(sections, home)
+	}
+
+	for _, dir := range b.filterList(root, directoryFilter) {
+		section, err := b.sectionPages(filepath.Join(root, dir.Name()), b.GroupRecursive)
+
+		if err != nil {
+			return nil, err
+		}
+
+		section.Name = b.createTitle(dir.Name())
+
+		// Directories on the overlay or on the mounted roots too.
+		source := b.source(filepath.Join(root, dir.Name()))
+
+		if title := metaString(b.indexMeta(source), "title"); title != "" {
+			section.Name = title
+		}
+
+		if meta := loadSection(source); meta != nil {
+			if title := metaString(meta, "title"); title != "" {
+				section.Name = title
+			}
+		}
+
+		sections = append(sections, section)
+	}
+
+	return sections, nil
+}
+
+// Returns a section for dir, with its link and its published pages (the
+// index of dir itself is left out), pages within subdirectories are included
+// if recursive is true.
+func (b *Builder) sectionPages(dir string, recursive bool) (Section, error) {
+	section := Section{Pages: []map[string]interface{}{}}
+
+	link, err := b.URLByPath(b.relPath(dir))
+
+	if err != nil {
+		return section, err
+	}
+
+	section.Link = link
+
+	err = b.walkPublished(dir, func(file string, info os.FileInfo, meta map[string]interface{}, url string) error {
+		if filepath.Dir(file) == dir {
+			if removeKnownExtension(info.Name()) == "index" {
+				return nil
+			}
+		} else if recursive == false {
+			return nil
+		}
+
+		item := map[string]interface{}{
+			"link":   url,
+			"text":   b.fileTitle(b.relPath(file)),
+			"weight": metaInt(meta, "weight"),
+		}
+
+		b.applyDate(item, meta)
+
+		section.Pages = append(section.Pages, item)
+
+		return nil
+	})
+
+	return section, err
+}
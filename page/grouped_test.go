@@ -0,0 +1,78 @@
+package page
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildGroupedIndex(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":                "# Home",
+		"about.md":                "# About",
+		"guide/index.md":          "# Guide",
+		"guide/intro.md":          "# Intro",
+		"guide/setup.md":          "# Setup",
+		"guide/basics/index.md":   "# Basics",
+		"guide/basics/one.md":     "# One",
+		"reference/_section.yaml": "title: API reference\n",
+		"reference/api.md":        "# API",
+		"reference/draft.md":      "---\ndraft: true\n---\n# Draft",
+		"_hidden/secret.md":       "# Secret",
+	})
+
+	b.Mounts = map[string]string{
+		"plugins": fixture(t, map[string]string{"index.md": "# Plugins", "cache.md": "# Cache"}),
+	}
+	b.Overlay = fixture(t, map[string]string{"extra/_section.yaml": "title: Extras\n", "extra/tips.md": "# Tips"})
+
+	links := func(sections []Section) map[string][]string {
+		out := map[string][]string{}
+		for _, section := range sections {
+			key := section.Name + " " + section.Link
+			out[key] = []string{}
+			for _, p := range section.Pages {
+				out[key] = append(out[key], p["link"].(string))
+			}
+		}
+		return out
+	}
+
+	sections, err := b.BuildGroupedIndex(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	for _, section := range sections {
+		names = append(names, section.Name)
+	}
+
+	if reflect.DeepEqual(names, []string{"Home", "Extras", "Guide", "Plugins", "API reference"}) == false {
+		t.Fatalf("Unexpected sections %v", names)
+	}
+
+	expected := map[string][]string{
+		"Home /":                    {"/about"},
+		"Extras /extra/":            {"/extra/tips"},
+		"Guide /guide/":             {"/guide/intro", "/guide/setup"},
+		"Plugins /plugins/":         {"/plugins/cache"},
+		"API reference /reference/": {"/reference/api"},
+	}
+
+	if got := links(sections); reflect.DeepEqual(got, expected) == false {
+		t.Fatalf("Expecting %v, got %v", expected, got)
+	}
+
+	b.GroupRecursive = true
+
+	sections, err = b.BuildGroupedIndex(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected["Guide /guide/"] = []string{"/guide/basics/", "/guide/basics/one", "/guide/intro", "/guide/setup"}
+
+	if got := links(sections); reflect.DeepEqual(got, expected) == false {
+		t.Fatalf("Expecting %v, got %v", expected, got)
+	}
+}
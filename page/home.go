@@ -0,0 +1,78 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"html/template"
+)
+
+// Returns a card for every top-level directory: its "link", its "text" (the
+// title of its index page, or its section title) and its "excerpt" (the
+// description of its index page, or its lead paragraph). Only the beginning of
+// index pages is rendered, as for summaries (see PageSummary).
+func (b *Builder) homeSections() []map[string]interface{} {
+	sections := []map[string]interface{}{}
+
+	for _, dir := range b.filterList(b.Root, directoryFilter) {
+		directory := b.Root + PS + dir.Name()
+
+		item := map[string]interface{}{
+			"link": b.linkFor(dir.Name(), true, "/"),
+			"text": b.createTitle(dir.Name()),
+		}
+
+		b.applySection(item, directory)
+
+		index, found := b.findIndex(b.source(directory))
+		if found == false {
+			index, found = b.findIndex(b.MountedFile(directory))
+		}
+
+		if found {
+			meta, src, err := b.readSource(index)
+
+			if err == nil && b.isPublished(meta) {
+				content := string(b.render(index, sourceHead(src, b.TOCMarker)))
+
+				title := metaString(meta, "title")
+				if title == "" {
+					title = b.extractTitle(content)
+				}
+				if title != "" {
+					item["text"] = title
+				}
+
+				if description := metaString(meta, "description"); description != "" {
+					item["excerpt"] = template.HTML(template.HTMLEscapeString(description))
+				} else if lead := b.leadOf(content); lead != "" {
+					item["excerpt"] = template.HTML(lead)
+				}
+			}
+		}
+
+		sections = append(sections, item)
+	}
+
+	return sections
+}
@@ -0,0 +1,51 @@
+package page
+
+import (
+	"html/template"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHomeSections(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":                "# Home",
+		"about.md":                "# About",
+		"guide/index.md":          "# User guide\n\nLearn how to *use* it.\n\nMore.",
+		"reference/_section.yaml": "title: Reference\n",
+		"reference/index.md":      "---\ndescription: All the <details>.\n---\nNo headings here.",
+		"misc/notes.md":           "# Notes",
+	})
+
+	b.Mounts = map[string]string{
+		"plugins": fixture(t, map[string]string{"index.md": "# Plugins\n\nWhat *extends* it.\n\nMore."}),
+	}
+	b.Overlay = fixture(t, map[string]string{"extra/index.md": "# Extras\n\nOn the overlay.\n"})
+
+	p, err := b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]interface{}{
+		{"link": "/extra/", "text": "Extras", "excerpt": template.HTML("On the overlay.")},
+		{"link": "/guide/", "text": "User guide", "excerpt": template.HTML("Learn how to <em>use</em> it.")},
+		{"link": "/misc/", "text": "Misc"},
+		{"link": "/plugins/", "text": "Plugins", "excerpt": template.HTML("What <em>extends</em> it.")},
+		{"link": "/reference/", "text": "Reference", "excerpt": template.HTML("All the &lt;details&gt;.")},
+	}
+
+	if reflect.DeepEqual(p.HomeSections, expected) == false {
+		t.Fatalf("Expecting %v, got %v", expected, p.HomeSections)
+	}
+
+	for _, file := range []string{"about.md", "guide/index.md"} {
+		p, err = b.Build(filepath.Join(b.Root, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p.HomeSections) != 0 {
+			t.Fatalf("%s: expecting no home sections, got %v", file, p.HomeSections)
+		}
+	}
+}
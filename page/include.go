@@ -0,0 +1,236 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// An include directive on a line of its own, i.e: {{ include "changelog.md" }}
+var includePattern = regexp.MustCompile(`(?m)^[ \t]*\{\{\s*include\s+"([^"]+)"\s*\}\}[ \t]*$`)
+
+// How deep includes may be nested, so a file can't include itself forever.
+const maxIncludeDepth = 8
+
+const (
+	defaultRemoteIncludeTimeout  = time.Second * 5
+	defaultRemoteIncludeMaxBytes = 1 << 20
+)
+
+// Replaces include directives in the source of file with the source of the
+// files they name, relative to file's directory. URLs are fetched only if
+// their host is on AllowRemoteIncludes. Includes that can't be read are
+// replaced with an HTML comment saying why. Directives in the code of
+// markdown files are left as they are.
+func (b *Builder) expandIncludes(file string, src []byte, depth int) []byte {
+	matches := includePattern.FindAllSubmatchIndex(src, -1)
+
+	if len(matches) == 0 {
+		return src
+	}
+
+	var code [][]int
+	if b.rendererOf(file) == RENDERER_MARKDOWN {
+		code = markdownCode(src)
+	}
+
+	var out bytes.Buffer
+
+	offset := 0
+
+	for _, m := range matches {
+		if inCode(code, m[0]) {
+			continue
+		}
+		out.Write(src[offset:m[0]])
+		out.Write(b.include(file, string(src[m[2]:m[3]]), depth))
+		offset = m[1]
+	}
+
+	out.Write(src[offset:])
+
+	return out.Bytes()
+}
+
+// Returns the expanded source of the file (or URL) an include directive of
+// file names.
+func (b *Builder) include(file string, name string, depth int) []byte {
+	if depth >= maxIncludeDepth {
+		return includeError(name, fmt.Errorf("too many nested includes"))
+	}
+
+	var included []byte
+	var err error
+
+	if isExternalLinkPattern.MatchString(name) {
+		included, err = b.fetchInclude(name)
+	} else {
+		name, err = b.includeFile(file, name)
+		if err == nil {
+			_, included, err = b.readSource(b.source(name))
+		}
+	}
+
+	if err != nil {
+		Logger.Printf("Could not include %s in %s: %s\n", name, file, err.Error())
+		return includeError(name, err)
+	}
+
+	return b.expandIncludes(name, included, depth+1)
+}
+
+// Returns the file an include directive of file names: the one next to file,
+// or else the first one found on the IncludePaths. Files outside of the
+// content root and of the IncludePaths can't be included.
+func (b *Builder) includeFile(file string, name string) (string, error) {
+	roots := append([]string{b.Root}, b.IncludePaths...)
+
+	candidates := []string{path.Join(path.Dir(file), name)}
+	for _, dir := range b.IncludePaths {
+		candidates = append(candidates, path.Join(dir, name))
+	}
+
+	allowed := []string{}
+
+	for _, candidate := range candidates {
+		for _, root := range roots {
+			if strings.HasPrefix(candidate, strings.TrimRight(root, PS)+PS) {
+				allowed = append(allowed, candidate)
+				break
+			}
+		}
+	}
+
+	if len(allowed) == 0 {
+		return candidates[0], fmt.Errorf("outside of the content root")
+	}
+
+	for _, candidate := range allowed {
+		if _, err := os.Stat(b.source(candidate)); err == nil {
+			return candidate, nil
+		}
+	}
+
+	// Not found, reading it tells why.
+	return allowed[0], nil
+}
+
+func includeError(name string, err error) []byte {
+	message := strings.Replace(err.Error(), "--", "- -", -1)
+	return []byte(fmt.Sprintf("<!-- Could not include %s: %s -->", name, message))
+}
+
+// Tells whether includes may be fetched from the given URL's host, allowed
+// hosts may be given with or without a port.
+func (b *Builder) isRemoteIncludeAllowed(u *url.URL) bool {
+	for _, allowed := range b.AllowRemoteIncludes {
+		if strings.EqualFold(allowed, u.Host) || strings.EqualFold(allowed, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns an error unless includes may be fetched from the given URL.
+func (b *Builder) checkRemoteInclude(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || b.isRemoteIncludeAllowed(u) == false {
+		return fmt.Errorf("host %s is not allowed", u.Host)
+	}
+	return nil
+}
+
+// Fetches a remote include, waiting no longer than RemoteIncludeTimeout and
+// reading no more than RemoteIncludeMaxBytes. Redirects are only followed to
+// allowed hosts.
+func (b *Builder) fetchInclude(rawurl string) ([]byte, error) {
+	u, err := url.Parse(rawurl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.checkRemoteInclude(u); err != nil {
+		return nil, err
+	}
+
+	timeout := b.RemoteIncludeTimeout
+	if timeout <= 0 {
+		timeout = defaultRemoteIncludeTimeout
+	}
+
+	limit := b.RemoteIncludeMaxBytes
+	if limit <= 0 {
+		limit = defaultRemoteIncludeMaxBytes
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("too many redirects")
+			}
+			return b.checkRemoteInclude(req.URL)
+		},
+	}
+
+	res, err := client.Get(rawurl)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %s", res.Status)
+	}
+
+	buf, err := ioutil.ReadAll(&io.LimitedReader{R: res.Body, N: limit + 1})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(buf)) > limit {
+		return nil, fmt.Errorf("larger than %d bytes", limit)
+	}
+
+	_, src, err := splitFrontMatter(buf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(src, "\n"), nil
+}
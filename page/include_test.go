@@ -0,0 +1,224 @@
+package page
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalInclude(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":      "# Home\n\n{{ include \"parts/news.md\" }}\n",
+		"parts/news.md": "---\ntitle: News\n---\n* Item\n\n{{ include \"more.md\" }}\n",
+		"parts/more.md": "*More*",
+		"loop.md":       "{{ include \"loop.md\" }}",
+		"outside.md":    "{{ include \"../../etc/passwd\" }}",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), "<li>Item</li>") == false ||
+		strings.Contains(string(p.Content), "<em>More</em>") == false {
+		t.Fatalf("Expecting nested includes to be rendered, got %q", p.Content)
+	}
+
+	for _, file := range []string{"loop.md", "outside.md"} {
+		p, err = b.Build(filepath.Join(b.Root, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(p.Content), "<!-- Could not include") == false {
+			t.Fatalf("%s: expecting the include to be refused, got %q", file, p.Content)
+		}
+	}
+}
+
+func TestIncludeInCode(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md": "# Home\n\n```\n{{ include \"part.md\" }}\n```\n\n" +
+			"Text.\n\n    {{ include \"part.md\" }}\n\n{{ include \"part.md\" }}\n",
+		"part.md": "*Part*",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(string(p.Content), "<em>Part</em>") != 1 {
+		t.Fatalf("Expecting only the include outside of code to be expanded, got %q", p.Content)
+	}
+
+	if strings.Count(string(p.Content), "{{ include") != 2 {
+		t.Fatalf("Expecting the includes in code to be left alone, got %q", p.Content)
+	}
+}
+
+func TestIncludePaths(t *testing.T) {
+	partials := fixture(t, map[string]string{
+		"partials/note.md": "> Shared note.\n\n{{ include \"sign.md\" }}\n",
+		"partials/sign.md": "*The team*",
+		"secret.md":        "Not to be included.",
+	})
+
+	b := testBuilder(t, map[string]string{
+		"index.md":   "# Home\n\n{{ include \"note.md\" }}\n",
+		"escape.md":  "{{ include \"../secret.md\" }}",
+		"missing.md": "{{ include \"nowhere.md\" }}",
+	})
+
+	b.IncludePaths = []string{partials + "/partials"}
+
+	p, err := b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), "Shared note.") == false || strings.Contains(string(p.Content), "<em>The team</em>") == false {
+		t.Fatalf("Expecting the include from the include path, got %q", p.Content)
+	}
+
+	for _, file := range []string{"escape.md", "missing.md"} {
+		p, err = b.Build(filepath.Join(b.Root, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(p.Content), "<!-- Could not include") == false || strings.Contains(string(p.Content), "Not to be included") {
+			t.Fatalf("%s: expecting the include to be refused, got %q", file, p.Content)
+		}
+	}
+}
+
+func TestRemoteInclude(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow.md" {
+			time.Sleep(500 * time.Millisecond)
+		}
+		w.Write([]byte("## Changelog\n\n* Fixed things.\n"))
+	}))
+	defer server.Close()
+
+	b := testBuilder(t, map[string]string{
+		"index.md": "# Home\n\n{{ include \"" + server.URL + "/changelog.md\" }}\n",
+		"slow.md":  "# Slow\n\n{{ include \"" + server.URL + "/slow.md\" }}\n",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), "<h2>Changelog</h2>") == true {
+		t.Fatalf("Expecting remote includes to be refused by default.")
+	}
+
+	u, _ := url.Parse(server.URL)
+	b.AllowRemoteIncludes = []string{u.Hostname()}
+
+	p, err = b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), "<li>Fixed things.</li>") == false {
+		t.Fatalf("Expecting the remote include to be rendered, got %q", p.Content)
+	}
+
+	b.RemoteIncludeTimeout = 50 * time.Millisecond
+
+	p, err = b.Build(filepath.Join(b.Root, "slow.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), "<!-- Could not include") == false {
+		t.Fatalf("Expecting the slow include to time out, got %q", p.Content)
+	}
+
+	b.RemoteIncludeTimeout = 0
+	b.RemoteIncludeMaxBytes = 8
+
+	p, err = b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), "larger than 8 bytes") == false {
+		t.Fatalf("Expecting the include to be refused for its size, got %q", p.Content)
+	}
+}
+
+func TestRemoteIncludeRedirects(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Internal secret"))
+	}))
+	defer internal.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/away.md":
+			http.Redirect(w, r, internal.URL+"/secret.md", http.StatusFound)
+		case "/moved.md":
+			http.Redirect(w, r, "/changelog.md", http.StatusMovedPermanently)
+		default:
+			w.Write([]byte("* Fixed things.\n"))
+		}
+	}))
+	defer server.Close()
+
+	b := testBuilder(t, map[string]string{
+		"away.md":  "{{ include \"" + server.URL + "/away.md\" }}\n",
+		"moved.md": "{{ include \"" + server.URL + "/moved.md\" }}\n",
+	})
+
+	u, _ := url.Parse(server.URL)
+	b.AllowRemoteIncludes = []string{u.Host}
+
+	p, err := b.Build(filepath.Join(b.Root, "away.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), "Internal secret") || strings.Contains(string(p.Content), "is not allowed") == false {
+		t.Fatalf("Expecting redirects to other hosts to be refused, got %q", p.Content)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "moved.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), "<li>Fixed things.</li>") == false {
+		t.Fatalf("Expecting redirects within the allowed host to be followed, got %q", p.Content)
+	}
+}
+
+func TestFooterInclude(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/_footer.md":      "Footer\n\n{{ include \"../_snippets/license.md\" }}\n",
+		"_snippets/license.md":  "Released under the *MIT* license.",
+		"guide/_header.html":    "<div>Header</div>\n{{ include \"../_snippets/banner.html\" }}\n",
+		"_snippets/banner.html": "<b>Banner</b>",
+		"guide/intro.md":        "# Intro",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "guide", "intro.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.ContentFooter), "Released under the <em>MIT</em> license.") == false {
+		t.Fatalf("Expecting the snippet in the footer, got %q", p.ContentFooter)
+	}
+
+	if strings.Contains(string(p.ContentHeader), "\n<b>Banner</b>") == false {
+		t.Fatalf("Expecting the snippet in the header, got %q", p.ContentHeader)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	md "github.com/russross/blackfriday"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+var inlineTagPattern = regexp.MustCompile(`<(/?)([a-zA-Z0-9]+)[^>]*>`)
+
+// Tags allowed on menu titles.
+var inlineTags = map[string]bool{
+	"b":      true,
+	"code":   true,
+	"em":     true,
+	"i":      true,
+	"small":  true,
+	"strong": true,
+	"sub":    true,
+	"sup":    true,
+}
+
+// Renders a short piece of inline markdown or HTML (i.e: a menu title), only
+// a few inline tags are kept, without attributes; everything else is dropped.
+func renderInline(s string) template.HTML {
+	out := strings.TrimSpace(string(md.MarkdownBasic([]byte(s))))
+	out = strings.TrimSuffix(strings.TrimPrefix(out, "<p>"), "</p>")
+
+	out = inlineTagPattern.ReplaceAllStringFunc(out, func(tag string) string {
+		found := inlineTagPattern.FindStringSubmatch(tag)
+		name := strings.ToLower(found[2])
+		if inlineTags[name] {
+			return "<" + found[1] + name + ">"
+		}
+		return ""
+	})
+
+	return template.HTML(strings.TrimSpace(out))
+}
+
+// Sets the text of a menu item to the "menu_title" of the given front matter
+// or section, if any. Unlike other titles it may contain inline markup.
+func applyMenuTitle(item map[string]interface{}, meta map[string]interface{}) {
+	if title := metaString(meta, "menu_title"); title != "" {
+		item["text"] = renderInline(title)
+	}
+}
@@ -0,0 +1,38 @@
+package page
+
+import (
+	"html/template"
+	"path/filepath"
+	"testing"
+)
+
+func TestMenuTitleMarkup(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"langs/index.md":         "# Languages",
+		"langs/cpp.md":           "---\nmenu_title: \"C<sup>++</sup> <script>alert(1)</script>\"\n---\n# C++\n",
+		"langs/go-lang.md":       "# Go\n",
+		"langs/tm/_section.yaml": "menu_title: \"Acme**™**\"\n",
+		"langs/tm/index.md":      "# Acme",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "langs", "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.SideMenu) != 2 {
+		t.Fatalf("Expecting two side menu entries, got %v", p.SideMenu)
+	}
+
+	if text, ok := p.SideMenu[0]["text"].(template.HTML); !ok || text != "C<sup>++</sup> alert(1)" {
+		t.Fatalf("Expecting an HTML title, got %#v", p.SideMenu[0]["text"])
+	}
+
+	if text, ok := p.SideMenu[1]["text"].(string); !ok || text != "Go lang" {
+		t.Fatalf("Expecting a plain title, got %#v", p.SideMenu[1]["text"])
+	}
+
+	if text := p.Menu[0]["text"]; text != template.HTML("Acme<strong>™</strong>") {
+		t.Fatalf("Expecting an HTML section title, got %#v", text)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A dated page, as listed on feeds.
+type feedEntry struct {
+	file string
+	url  string
+	date time.Time
+	meta map[string]interface{}
+}
+
+// Returns the published pages under dir (relative to the content root) that
+// have a date, newest first. Directory indexes are left out.
+func (b *Builder) feedEntries(dir string) ([]feedEntry, error) {
+	rel := strings.Trim(path.Clean("/"+dir), "/")
+
+	entries := []feedEntry{}
+
+	err := b.walkPublished(strings.TrimRight(b.Root+PS+rel, PS), func(file string, info os.FileInfo, meta map[string]interface{}, url string) error {
+		date, ok := parseDate(meta["date"])
+		if ok == false || removeKnownExtension(info.Name()) == "index" {
+			return nil
+		}
+		entries = append(entries, feedEntry{file: file, url: url, date: date, meta: meta})
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].date.After(entries[j].date)
+	})
+
+	return entries, nil
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	Title         string `json:"title,omitempty"`
+	ContentHTML   string `json:"content_html"`
+	Summary       string `json:"summary,omitempty"`
+	DatePublished string `json:"date_published"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+// Returns a JSON Feed (see jsonfeed.org, version 1.1) of the dated pages
+// under dir (relative to the content root), newest first. baseURL is the
+// scheme and host the site is served at (i.e: "http://example.org").
+func (b *Builder) BuildJSONFeed(dir string, baseURL string) ([]byte, error) {
+	entries, err := b.feedEntries(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rel := strings.Trim(path.Clean("/"+dir), "/")
+
+	index := b.NewPage(strings.TrimRight(b.Root+PS+rel, PS) + PS + "index")
+
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       b.fileTitle(rel + "/index"),
+		HomePageURL: b.absoluteURL(baseURL, index.Link),
+		Items:       []jsonFeedItem{},
+	}
+
+	if title := metaString(b.indexMeta(b.Root+PS+rel), "title"); title != "" {
+		feed.Title = title
+	}
+
+	for _, entry := range entries {
+		_, src, err := b.readSource(b.source(entry.file))
+
+		if err != nil {
+			return nil, err
+		}
+
+		content, _ := b.NewPage(entry.file).renderContent(src)
+
+		title := metaString(entry.meta, "title")
+		if title == "" {
+			title = b.extractTitle(string(content))
+		}
+
+		// Page URLs include the Prefix already.
+		url := b.pageURL(entry.meta, baseURL, strings.TrimPrefix(entry.url, b.mountPath()))
+
+		feed.Items = append(feed.Items, jsonFeedItem{
+			ID:            url,
+			URL:           url,
+			Title:         title,
+			ContentHTML:   string(content),
+			Summary:       metaString(entry.meta, "description"),
+			DatePublished: entry.date.Format(time.RFC3339),
+		})
+	}
+
+	return json.MarshalIndent(feed, "", "  ")
+}
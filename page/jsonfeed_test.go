@@ -0,0 +1,55 @@
+package page
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBuildJSONFeed(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"blog/index.md":   "---\ntitle: Our blog\n---\n# Blog",
+		"blog/old.md":     "---\ndate: 2013-01-05\n---\n# Old news\n\nFirst.",
+		"blog/new.md":     "---\ndate: 2013-03-01\ndescription: Fresh\n---\n# New news\n\nLatest.",
+		"blog/undated.md": "# Undated",
+		"blog/draft.md":   "---\ndate: 2013-02-01\ndraft: true\n---\n# Draft",
+		"other/post.md":   "---\ndate: 2013-02-01\n---\n# Elsewhere",
+	})
+
+	buf, err := b.BuildJSONFeed("blog", "http://example.org/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var feed struct {
+		Version     string `json:"version"`
+		Title       string `json:"title"`
+		HomePageURL string `json:"home_page_url"`
+		Items       []map[string]string
+	}
+
+	if err := json.Unmarshal(buf, &feed); err != nil {
+		t.Fatalf("Expecting valid JSON, got %s: %s", err, buf)
+	}
+
+	if feed.Version != "https://jsonfeed.org/version/1.1" || feed.Title != "Our blog" || feed.HomePageURL != "http://example.org/blog/" {
+		t.Fatalf("Unexpected feed %+v", feed)
+	}
+
+	if len(feed.Items) != 2 {
+		t.Fatalf("Expecting two items, got %v", feed.Items)
+	}
+
+	first, second := feed.Items[0], feed.Items[1]
+
+	if first["url"] != "http://example.org/blog/new" || second["url"] != "http://example.org/blog/old" {
+		t.Fatalf("Expecting the newest first with absolute URLs, got %s and %s", first["url"], second["url"])
+	}
+
+	if first["title"] != "New news" || first["summary"] != "Fresh" || first["date_published"] != "2013-03-01T00:00:00Z" {
+		t.Fatalf("Unexpected item %v", first)
+	}
+
+	if first["content_html"] == "" || first["id"] != first["url"] {
+		t.Fatalf("Unexpected item %v", first)
+	}
+}
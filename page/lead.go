@@ -0,0 +1,47 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	leadPattern     = regexp.MustCompile(`(?s)<p>(.*?)</p>\s*`)
+	headingsPattern = regexp.MustCompile(`(?s)^(\s*(<h[1-6][^>]*>.*?</h[1-6]>|` + regexp.QuoteMeta(tocPlaceholder) + `))*\s*$`)
+)
+
+// Returns the first paragraph of content when it's only preceded by headings
+// (or the placeholder of a table of contents), and the content with that
+// paragraph removed.
+func extractLead(content string) (string, string) {
+	loc := leadPattern.FindStringSubmatchIndex(content)
+
+	if loc == nil || headingsPattern.MatchString(content[:loc[0]]) == false {
+		return "", content
+	}
+
+	return strings.TrimSpace(content[loc[2]:loc[3]]), content[:loc[0]] + content[loc[1]:]
+}
@@ -0,0 +1,43 @@
+package page
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLead(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"article.md":  "# Article\n\nThe *intro* paragraph.\n\nThe body.\n",
+		"headings.md": "# One\n\n## Two\n\n### Three\n",
+		"list.md":     "# List\n\n* item\n\nNot a lead.\n",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "article.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Lead != "The <em>intro</em> paragraph." {
+		t.Fatalf("Expecting the intro paragraph, got %q", p.Lead)
+	}
+
+	if strings.Contains(string(p.Content), "intro") == false {
+		t.Fatalf("Expecting the lead to be kept in the content, got %q", p.Content)
+	}
+
+	b.RemoveLead = true
+
+	p, _ = b.Build(filepath.Join(b.Root, "article.md"))
+
+	if strings.Contains(string(p.Content), "intro") || strings.Contains(string(p.Content), "The body.") == false {
+		t.Fatalf("Expecting the lead to be removed from the content, got %q", p.Content)
+	}
+
+	for _, name := range []string{"headings.md", "list.md"} {
+		p, _ = b.Build(filepath.Join(b.Root, name))
+		if p.Lead != "" {
+			t.Fatalf("%s: expecting no lead, got %q", name, p.Lead)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+	"strings"
+)
+
+// Name of the template a directory can use to lay out its own listing.
+const listingFile = "_listing.html"
+
+// Used for listings when neither the directory nor the builder has a template.
+const defaultListing = `<h1>{{ .Title }}</h1>
+<ul class="listing">
+{{ range .Items }}  <li class="{{ .type }}"><a href="{{ .link }}">{{ .text }}</a></li>
+{{ end }}</ul>
+`
+
+// What listing templates are executed with.
+type listing struct {
+	// Title of the directory.
+	Title string
+	// Link to the directory.
+	Link string
+	// Subdirectories, then pages.
+	Items []map[string]interface{}
+	// Subdirectories only.
+	Dirs []map[string]interface{}
+	// Pages only.
+	Pages []map[string]interface{}
+}
+
+// Returns the subdirectories and pages of dir (relative to the content root)
+// as a single list, each item has a "type" key set to either "dir" or "page".
+// Directories go before pages if dirsFirst is true, after them otherwise.
+func (b *Builder) BuildListing(dir string, dirsFirst bool) ([]map[string]interface{}, error) {
+	rel := strings.Trim(path.Clean("/"+dir), "/")
+
+	directory := b.Root + PS + rel
+
+	stat, err := os.Stat(b.MountedFile(directory))
+
+	if err != nil {
+		return nil, fmt.Errorf("Could not list %s: %s", dir, err.Error())
+	}
+
+	if stat.IsDir() == false {
+		return nil, fmt.Errorf("Could not list %s: not a directory.", dir)
+	}
+
+	prefix := "/"
+	if rel != "" {
+		prefix = "/" + rel + "/"
+	}
+
+	p := b.NewPage(directory + PS + "index")
+
+	dirs := []map[string]interface{}{}
+	for _, file := range b.filterList(directory, directoryFilter) {
+		item := p.CreateLink(file, prefix)
+		b.applySection(item, directory+PS+file.Name())
+		item["type"] = "dir"
+		dirs = append(dirs, item)
+	}
+
+	pages := []map[string]interface{}{}
+	for _, file := range b.filterList(directory, b.pageFilter) {
+		if removeKnownExtension(file.Name()) == "index" || b.isHomeDocument(directory+PS+file.Name()) || isShadowed(directory, file.Name()) {
+			continue
+		}
+		meta, err := b.readMeta(b.source(directory + PS + file.Name()))
+		if err == nil && b.isPublished(meta) == false {
+			continue
+		}
+		if b.isMenuFile(directory, file.Name(), meta) == false {
+			continue
+		}
+		keep := b.keepsExtension(directory, file.Name(), meta)
+		item := p.CreateLink(file, prefix)
+		if b.applySlug(item, directory, file.Name(), meta, prefix) == false {
+			continue
+		}
+		if keep {
+			item["link"] = prefix + file.Name()
+		}
+		item["type"] = "page"
+		item["weight"] = metaInt(meta, "weight")
+		b.applyDate(item, meta)
+		pages = append(pages, item)
+	}
+
+	section := loadSection(directory)
+
+	sortItems(dirs, section)
+	sortItems(pages, section)
+
+	if dirsFirst {
+		return append(dirs, pages...), nil
+	}
+
+	return append(pages, dirs...), nil
+}
+
+// Tells whether the given directory exists and is not hidden.
+func (b *Builder) isListable(dir string) bool {
+	stat, err := os.Stat(dir)
+
+	if err != nil || stat.IsDir() == false {
+		return false
+	}
+
+	for _, chunk := range strings.Split(b.relPath(b.unmountedFile(dir)), "/") {
+		if isHidden(chunk) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns the template a directory's listing is rendered with: its own
+// _listing.html, the builder's ListingTemplate or the built-in one.
+func (b *Builder) listingTemplate(directory string) (*template.Template, error) {
+	for _, file := range []string{directory + PS + listingFile, b.ListingTemplate} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err == nil {
+			tpl, err := template.ParseFiles(file)
+			if err != nil {
+				return nil, fmt.Errorf("Error trying to parse listing template %s: %s", file, err.Error())
+			}
+			return tpl, nil
+		}
+	}
+	return template.Must(template.New("listing").Parse(defaultListing)), nil
+}
+
+// Returns a page listing the subdirectories and pages of dir (relative to the
+// content root), its content is the directory's listing template executed
+// with them. Returns ErrNotFound unless AutoIndex is set.
+func (b *Builder) BuildDirectoryIndex(dir string) (*Page, error) {
+	if b.AutoIndex == false {
+		return nil, ErrNotFound
+	}
+
+	items, err := b.BuildListing(dir, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rel := strings.Trim(path.Clean("/"+dir), "/")
+
+	directory := b.Root + PS + rel
+
+	p := b.NewPage(strings.TrimRight(directory, PS) + PS + "index")
+
+	p.Title = b.fileTitle(rel + "/index")
+
+	data := listing{
+		Title: p.Title,
+		Link:  p.Link,
+		Items: items,
+		Dirs:  []map[string]interface{}{},
+		Pages: []map[string]interface{}{},
+	}
+
+	for _, item := range items {
+		if item["type"] == "dir" {
+			data.Dirs = append(data.Dirs, item)
+		} else {
+			data.Pages = append(data.Pages, item)
+		}
+	}
+
+	tpl, err := b.listingTemplate(directory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	if err = tpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("Error trying to render listing of %s: %s", dir, err.Error())
+	}
+
+	p.Content = template.HTML(buf.String())
+
+	p.CreateBreadCrumb()
+	p.CreateParent()
+	p.CreateMenu()
+	p.CreateSideMenu()
+
+	p.ETag = p.etag()
+
+	return p, nil
+}
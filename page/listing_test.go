@@ -0,0 +1,254 @@
+package page
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildListing(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md":          "# Guide",
+		"guide/zeta.md":           "# Zeta",
+		"guide/alpha.md":          "# Alpha",
+		"guide/advanced/index.md": "# Advanced",
+		"guide/basics/index.md":   "# Basics",
+	})
+
+	expect := func(listing []map[string]interface{}, links ...string) {
+		if len(listing) != len(links) {
+			t.Fatalf("Expecting %d items, got %v", len(links), listing)
+		}
+		for i, link := range links {
+			if listing[i]["link"] != link {
+				t.Fatalf("Expecting %s at %d, got %v", link, i, listing[i])
+			}
+		}
+	}
+
+	listing, err := b.BuildListing("guide", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(listing, "/guide/advanced/", "/guide/basics/", "/guide/alpha", "/guide/zeta")
+
+	if listing[0]["type"] != "dir" || listing[2]["type"] != "page" {
+		t.Fatalf("Expecting type keys, got %v", listing)
+	}
+
+	listing, err = b.BuildListing("guide", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(listing, "/guide/alpha", "/guide/zeta", "/guide/advanced/", "/guide/basics/")
+
+	if _, err := b.BuildListing("missing", true); err == nil {
+		t.Fatalf("Expecting an error for a missing directory.")
+	}
+}
+
+func TestBuildDirectoryIndex(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":              "# Home",
+		"notes/alpha.md":        "# Alpha",
+		"notes/old/index.md":    "# Old",
+		"custom/_listing.html":  `{{ range .Pages }}[{{ .link }}]{{ end }}{{ range .Dirs }}({{ .link }}){{ end }}`,
+		"custom/beta.md":        "# Beta",
+		"custom/archive/one.md": "# One",
+	})
+
+	p, err := b.BuildDirectoryIndex("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := string(p.Content)
+	if strings.Contains(content, `<li class="page"><a href="/notes/alpha">Alpha</a></li>`) == false ||
+		strings.Contains(content, `<li class="dir"><a href="/notes/old/">Old</a></li>`) == false {
+		t.Fatalf("Expecting the built-in listing, got %q", content)
+	}
+
+	if p.Title != "Notes" || len(p.BreadCrumb) != 2 {
+		t.Fatalf("Unexpected title %q or breadcrumb %v", p.Title, p.BreadCrumb)
+	}
+
+	p, err = b.BuildDirectoryIndex("custom")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(p.Content) != "[/custom/beta](/custom/archive/)" {
+		t.Fatalf("Expecting the directory's own listing template, got %q", p.Content)
+	}
+
+	global := filepath.Join(t.TempDir(), "listing.html")
+	if err := ioutil.WriteFile(global, []byte(`{{ len .Items }} items`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b.ListingTemplate = global
+
+	p, err = b.BuildDirectoryIndex("notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(p.Content) != "2 items" {
+		t.Fatalf("Expecting the builder's listing template, got %q", p.Content)
+	}
+}
+
+func TestResolveAutoIndex(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"notes/alpha.md": "# Alpha",
+		"_hidden/a.md":   "# A",
+	})
+
+	if _, transform := b.Resolve(b.Root + "/notes/"); transform != LISTING_TRANSFORM {
+		t.Fatalf("Expecting a listing, got %d", transform)
+	}
+
+	if _, err := b.BuildDirectoryIndex("notes"); err != nil {
+		t.Fatalf("Expecting a listing, got %v", err)
+	}
+
+	if _, transform := b.Resolve(b.Root + "/notes"); transform != REDIRECT_TRANSFORM {
+		t.Fatalf("Expecting a redirect, got %d", transform)
+	}
+
+	if _, transform := b.Resolve(b.Root + "/_hidden/"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting hidden directories not to be listed, got %d", transform)
+	}
+
+	b.AutoIndex = false
+
+	if _, transform := b.Resolve(b.Root + "/notes/"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting no listing unless AutoIndex is set, got %d", transform)
+	}
+
+	if _, err := b.BuildDirectoryIndex("notes"); err != ErrNotFound {
+		t.Fatalf("Expecting ErrNotFound, got %v", err)
+	}
+}
+
+func TestListingDates(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"news/launch.md":  "---\ndate: 2013-03-09\n---\n# Launch",
+		"news/undated.md": "# Undated",
+	})
+
+	formatted := func() interface{} {
+		items, err := b.BuildListing("news", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := items[1]["date"]; ok == true {
+			t.Fatalf("Expecting no date for undated pages, got %v", items[1])
+		}
+		return items[0]["date_formatted"]
+	}
+
+	if f := formatted(); f != "March 9, 2013" {
+		t.Fatalf("Expecting the default layout, got %v", f)
+	}
+
+	b.DateFormat = "02/01/2006"
+
+	if f := formatted(); f != "09/03/2013" {
+		t.Fatalf("Expecting the configured layout, got %v", f)
+	}
+
+	p := b.NewPage(filepath.Join(b.Root, "news", "undated.md"))
+	p.CreateSideMenu()
+
+	if p.SideMenu[0]["date_formatted"] != "09/03/2013" {
+		t.Fatalf("Expecting dates on the side menu, got %v", p.SideMenu[0])
+	}
+
+	b.DateFormat = "Monday, 2 January 2006"
+	b.DateLocale = "es_MX"
+
+	if f := formatted(); f != "sábado, 9 marzo 2013" {
+		t.Fatalf("Expecting names in the configured locale, got %v", f)
+	}
+
+	b.DateLocale = "xx"
+
+	if f := formatted(); f != "Saturday, 9 March 2013" {
+		t.Fatalf("Expecting English names for unknown locales, got %v", f)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2013, time.March, 9, 15, 4, 0, 0, time.UTC)
+
+	tests := []struct {
+		layout, locale, expected string
+	}{
+		{"Jan 2, 2006", "", "Mar 9, 2013"},
+		{"Mon Jan 2 15:04", "fr", "sam. mars 9 15:04"},
+		{"2 January 2006 (Monday)", "de", "9 März 2013 (Samstag)"},
+		{"2006-01-02", "pt-BR", "2013-03-09"},
+	}
+
+	for _, test := range tests {
+		if f := formatDate(date, test.layout, test.locale); f != test.expected {
+			t.Fatalf("Expecting %q with %q in %q, got %q", test.expected, test.layout, test.locale, f)
+		}
+	}
+}
+
+func TestWeight(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"docs/heavy.md":          "---\nweight: 10\n---\n# Heavy",
+		"docs/plain.md":          "# Plain",
+		"docs/sub/_section.yaml": "weight: -5\n",
+		"docs/other/index.md":    "# Other",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "docs", "heavy.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Weight != 10 {
+		t.Fatalf("Expecting weight 10, got %d", p.Weight)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "docs", "plain.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Weight != 0 {
+		t.Fatalf("Expecting weight 0, got %d", p.Weight)
+	}
+
+	items, err := b.BuildListing("docs", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weights := map[string]interface{}{}
+	for _, item := range items {
+		weights[item["link"].(string)] = item["weight"]
+	}
+
+	expected := map[string]interface{}{
+		"/docs/other/": 0,
+		"/docs/sub/":   -5,
+		"/docs/heavy":  10,
+		"/docs/plain":  0,
+	}
+
+	if reflect.DeepEqual(weights, expected) == false {
+		t.Fatalf("Expecting weights %v, got %v", expected, weights)
+	}
+
+	for _, item := range p.SideMenu {
+		if item["weight"] != weights[item["link"].(string)] {
+			t.Fatalf("Expecting side menu weights to match the listing, got %v", p.SideMenu)
+		}
+	}
+}
@@ -24,24 +24,34 @@
 package page
 
 import (
+	"fmt"
 	"html/template"
+	"log"
 	"os"
 	"path"
 	"regexp"
 	"sort"
 	"strings"
-	"fmt"
+	"time"
 )
 
 // This structure holds information on the current document served by Luminos.
 type Page struct {
 
-	// Page title, guessed from the current document. (Looks for the first H1, H2, ..., H6 tag)
+	// Page title, guessed from the current document. (Looks for the first H1, H2, ..., H6 tag,
+	// falls back to a title made from the file name)
 	Title string
 
+	// Page description, from the "description" key of the front matter.
+	Description string
+
 	// The HTML of the current document.
 	Content template.HTML
 
+	// The HTML of the first paragraph of the current document, when it's only
+	// preceded by headings.
+	Lead template.HTML
+
 	// The HTML of the _header.md or _header.html file on the current document's directory.
 	ContentHeader template.HTML
 
@@ -52,6 +62,11 @@ type Page struct {
 	// Names begginning with "." or "_" are ignored in this list.
 	Menu []map[string]interface{}
 
+	// The menu of the root directory (or of the current document's top level
+	// section, see SectionTopMenu), for top level navigation. The entry of the
+	// section the current document is in has "active_section" set to true.
+	TopMenu []map[string]interface{}
+
 	// An array of maps that contains names and links of all the items on the current document's directory.
 	// Names begginning with "." or "_" are ignored in this list.
 	SideMenu []map[string]interface{}
@@ -62,12 +77,31 @@ type Page struct {
 	// A map that contains name and link of the current page.
 	CurrentPage map[string]interface{}
 
+	// A map that contains name and link of the directory above the current
+	// page, nil on the home page.
+	Parent map[string]interface{}
+
+	// Maps with names and links of the pages before and after the current
+	// one in reading order (see PrevNextAcrossSections), nil if there's none.
+	Prev map[string]interface{}
+	Next map[string]interface{}
+
+	// Position of the current page among the pages of its directory, in side
+	// menu order (i.e: "page {{ .SiblingIndex }} of {{ .SiblingCount }}",
+	// counting from 0). Directory indexes are not among them, their
+	// SiblingIndex is -1.
+	SiblingIndex int
+	SiblingCount int
+
 	// Absolute path of the current document.
 	FilePath string
 
 	// Absolute parent directory of the current document.
 	FileDir string
 
+	// Link of the current document.
+	Link string
+
 	// Relative path of the current document.
 	BasePath string
 
@@ -76,9 +110,108 @@ type Page struct {
 
 	// True if the current document is / (home).
 	IsHome bool
+
+	// Top-level sections, with their titles and excerpts, on the home page.
+	HomeSections []map[string]interface{}
+
+	// True if the requested document could not be found, see BuildNotFound.
+	IsNotFound bool
+
+	// Site data, from the _data directory.
+	Data map[string]interface{}
+
+	// Entity tag for HTTP caching, set once the page is built.
+	ETag string
+
+	// When the current document was last changed, see GitModTime.
+	ModTime time.Time
+
+	// Weight from the front matter, 0 if not set.
+	Weight int
+
+	// Robots directives from the front matter (i.e: "noindex,nofollow"), empty
+	// if the page may be indexed.
+	Robots string
+
+	// Metadata of the top level section (from its _section.yaml file) the
+	// current document is in.
+	Section map[string]interface{}
+
+	// Name of the top level directory the current document is in (i.e:
+	// "guide"), empty for documents on the root directory.
+	ActiveSection string
+
+	// Links of the stylesheets and scripts listed under the "styles" and
+	// "scripts" keys of the front matter, relative paths are resolved against
+	// the current document's directory.
+	Styles  []string
+	Scripts []string
+
+	// Front matter of the current document (the YAML block between "---"
+	// lines at the beginning of the file), only its AllowedMetaKeys if the
+	// builder has any.
+	Meta map[string]interface{}
+
+	// The whole front matter, for the page's own methods.
+	meta map[string]interface{}
+
+	// Whether the page is the index of the directory it shares its name with.
+	shadows bool
+
+	// What relative links on the content are relative to, when it's not
+	// BasePath (i.e: for a HomeDocument within a directory).
+	linkBase string
+
+	// Front matter of the current document exactly as it was parsed, without
+	// the profiles it extends merged in. Nested maps are
+	// map[interface{}]interface{}, as YAML decodes them.
+	FrontMatter map[string]interface{}
+
+	// Builder this page belongs to, if any.
+	builder *Builder
+
+	// Menu building steps, only recorded by CreateMenuTrace.
+	traceEvents *[]TraceEvent
+
+	// Subdirectories and content files of FileDir, see listDirectory.
+	listing *[2]fileList
 }
 
-var extensions = []string{".html", ".md", ""}
+// A step taken while building a menu.
+type TraceEvent struct {
+	// Directory, as a link relative to the content root.
+	Dir string
+	// What happened: "cached" (the menu came from the cache), "list"
+	// (subdirectories were listed), "children" (a subdirectory's children were
+	// listed) or "child" (a child was added).
+	Action string
+	// Number of entries involved.
+	Count int
+}
+
+func (p *Page) trace(dir string, action string, count int) {
+	if p.traceEvents != nil {
+		*p.traceEvents = append(*p.traceEvents, TraceEvent{Dir: dir, Action: action, Count: count})
+	}
+}
+
+// Like CreateMenu, but also returns every step that was taken to build the
+// menu.
+func (p *Page) CreateMenuTrace() []TraceEvent {
+	events := []TraceEvent{}
+	p.traceEvents = &events
+	p.CreateMenu()
+	p.traceEvents = nil
+	return events
+}
+
+var extensions = []string{".html", ".md", ".rst", ""}
+
+var isExternalLinkPattern = regexp.MustCompile(`^[a-zA-Z0-9]+:\/\/`)
+
+// Where debugging messages go, set it to log.New(ioutil.Discard, "", 0) to
+// silence them.
+var Logger = log.New(os.Stdout, "", 0)
 
 // Just a list of files that can be sorted.
 type fileList []os.FileInfo
@@ -116,25 +249,91 @@ func removeKnownExtension(s string) string {
 	return s
 }
 
-// Returns files in a directory passed through a filter.
-func filterList(directory string, filter func(os.FileInfo) bool) fileList {
-	var list fileList
+// Returns the whole front matter of the page, Meta may only have some of it.
+func (p *Page) fullMeta() map[string]interface{} {
+	if p.meta != nil {
+		return p.meta
+	}
+	return p.Meta
+}
 
+// Returns the entries of a directory, or as many as could be read.
+var readEntries = func(directory string) []os.DirEntry {
 	fp, err := os.Open(directory)
-	defer fp.Close()
 
 	if err != nil {
-		panic(err)
+		// The directory may have been removed while we were walking.
+		Logger.Printf("Could not open directory %s: %s\n", directory, err.Error())
+		return nil
 	}
 
-	ls, err := fp.Readdir(-1)
+	defer fp.Close()
+
+	ls, err := fp.ReadDir(-1)
 
 	if err != nil {
-		panic(err)
+		// Keep whatever we could read.
+		Logger.Printf("Could not read directory %s: %s\n", directory, err.Error())
+	}
+
+	return ls
+}
+
+// Returns files in a directory passed through a filter.
+func filterList(directory string, filter func(os.FileInfo) bool) fileList {
+	return filterEntries(directory, readEntries(directory), filter)
+}
+
+// Splits directory entries into subdirectories and the content files pages
+// accepts.
+func splitEntries(directory string, ls []os.DirEntry, pages func(os.FileInfo) bool) (fileList, fileList) {
+	var dirs, files fileList
+
+	for _, entry := range ls {
+		file, err := entry.Info()
+
+		if err != nil {
+			Logger.Printf("Skipping %s: %s\n", directory+PS+entry.Name(), err.Error())
+			continue
+		}
+
+		if directoryFilter(file) == true {
+			dirs = append(dirs, file)
+		} else if pages(file) == true {
+			files = append(files, file)
+		}
 	}
 
-	for _, file := range ls {
-		fmt.Printf("Considering >>[%s]\n", file.Name())
+	sort.Sort(byName{dirs})
+	sort.Sort(byName{files})
+
+	return dirs, files
+}
+
+// Returns the subdirectories and content files of the page's directory, the
+// directory is read once for CreateMenu and CreateSideMenu.
+func (p *Page) listDirectory() (fileList, fileList) {
+	if p.listing == nil {
+		dirs, files := splitEntries(p.FileDir, p.builder.readEntries(p.FileDir), p.builder.pageFilter)
+		p.listing = &[2]fileList{dirs, files}
+	}
+	return p.listing[0], p.listing[1]
+}
+
+// Passes directory entries through a filter, entries that can't be resolved
+// (i.e: files removed after the directory was read) are skipped.
+func filterEntries(directory string, ls []os.DirEntry, filter func(os.FileInfo) bool) fileList {
+	var list fileList
+
+	for _, entry := range ls {
+		file, err := entry.Info()
+
+		if err != nil {
+			Logger.Printf("Skipping %s: %s\n", directory+PS+entry.Name(), err.Error())
+			continue
+		}
+
+		Logger.Printf("Considering >>[%s]\n", file.Name())
 
 		if filter(file) == true {
 			list = append(list, file)
@@ -146,6 +345,67 @@ func filterList(directory string, filter func(os.FileInfo) bool) fileList {
 	return list
 }
 
+// Returns true for names that are not listed on menus (names beginning with
+// "." or "_").
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// Calls fn for every page under root (a directory within the content root),
+// in lexical order, hidden files and directories are skipped. Pages on the
+// overlay and on the roots mounted under root are passed with their paths
+// under the content root, as if they were there.
+func (b *Builder) walkPages(root string, fn func(file string, info os.FileInfo) error) error {
+	return b.walk(root, false, fn)
+}
+
+// Like walkPages, directories beginning with "_" are walked too: their pages
+// are served, they're only left out of menus.
+func (b *Builder) walkServedPages(root string, fn func(file string, info os.FileInfo) error) error {
+	return b.walk(root, true, fn)
+}
+
+func (b *Builder) walk(root string, underscored bool, fn func(file string, info os.FileInfo) error) error {
+	info, err := os.Stat(b.source(root))
+
+	if err != nil {
+		return err
+	}
+
+	return b.walkPagesIn(root, info, underscored, fn)
+}
+
+func (b *Builder) walkPagesIn(file string, info os.FileInfo, underscored bool, fn func(file string, info os.FileInfo) error) error {
+	if info.IsDir() == false {
+		if b.pageName(info.Name()) != info.Name() {
+			return fn(file, info)
+		}
+		return nil
+	}
+
+	// Merged with the overlay's, and with the roots mounted within.
+	ls := b.readEntries(file)
+
+	sort.Slice(ls, func(i, j int) bool {
+		return ls[i].Name() < ls[j].Name()
+	})
+
+	for _, entry := range ls {
+		if isHidden(entry.Name()) && (underscored == false || entry.IsDir() == false || strings.HasPrefix(entry.Name(), ".")) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if err := b.walkPagesIn(file+PS+entry.Name(), info, underscored, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // A filter for filterList. Returns all directories except those that begin with "." or "_".
 func directoryFilter(f os.FileInfo) bool {
 	if strings.HasPrefix(f.Name(), ".") == false && strings.HasPrefix(f.Name(), "_") == false {
@@ -154,115 +414,648 @@ func directoryFilter(f os.FileInfo) bool {
 	return false
 }
 
-// A filter for filterList. Returns all files except for those that 
-// begin with "." or "_", or end with "~" (applies to directory names, too,
-// unlike the original luminos)
+// A filter for filterList. Returns the files with a page extension (see
+// pageExtensions), except for those that begin with "." or "_".
+func pageFilter(f os.FileInfo) bool {
+	return isHidden(f.Name()) == false && f.IsDir() == false && removeKnownExtension(f.Name()) != f.Name()
+}
+
+// Like pageFilter, files with the extension of one of the Renderers are
+// pages too.
+func (b *Builder) pageFilter(f os.FileInfo) bool {
+	return isHidden(f.Name()) == false && f.IsDir() == false && b.pageName(f.Name()) != f.Name()
+}
 
-func mdFilter(f os.FileInfo) bool {
-	n := f.Name()
-	if strings.HasPrefix(n, ".") {
-		return false
+// Tells whether links to the file named name, within directory, keep its
+// extension (i.e: HTML files that are downloadable examples, not pages),
+// because of a "keep_extension: true" key on its front matter or on its
+// directory's _section.yaml. Such files are listed on side menus even if
+// they're not markdown files. Markdown, reStructuredText and the Renderers'
+// files are always rendered, so they never keep it.
+func (b *Builder) keepsExtension(directory string, name string, meta map[string]interface{}) bool {
+	ext := path.Ext(name)
+	for _, pageExt := range b.pageExtensions() {
+		if ext == pageExt && ext != ".html" {
+			return false
+		}
+	}
+	if keep, _ := meta["keep_extension"].(bool); keep {
+		return true
 	}
-	if strings.HasPrefix(n, "_") {
-		return false
+	keep, _ := loadSection(directory)["keep_extension"].(bool)
+	return keep
+}
+
+// Tells whether side menus list the file named name, within directory:
+// markdown and reStructuredText pages, pages of the Renderers and files that
+// keep their extension.
+func (b *Builder) isMenuFile(directory string, name string, meta map[string]interface{}) bool {
+	if ext := path.Ext(name); ext == ".md" || ext == ".rst" {
+		return true
 	}
-	if !strings.HasSuffix(n, ".md") {
-		return false
+	if b != nil && path.Ext(name) != ".html" && b.Renderers[path.Ext(name)] != "" {
+		return true
 	}
-	return true
+	return b.keepsExtension(directory, name, meta)
+}
+
+// Returns the content of a file that keeps its extension, without its front
+// matter, so it can be served as it is. Returns false for any other file.
+func (b *Builder) KeptSource(file string) ([]byte, bool) {
+	if path.Ext(file) != ".html" {
+		return nil, false
+	}
+
+	meta, src, err := b.readSource(file)
+
+	if err != nil || b.keepsExtension(path.Dir(file), path.Base(file), meta) == false {
+		return nil, false
+	}
+
+	return src, true
+}
+
+// Tells whether the page named name, within directory, shares its name with
+// a directory next to it (which then takes its place, see findIndex).
+func isShadowed(directory string, name string) bool {
+	stat, err := os.Stat(strings.TrimRight(directory, PS) + PS + removeKnownExtension(name))
+	return err == nil && stat.IsDir()
 }
 
 // Returns a stylized human title, given a file name.
 func createTitle(s string) string {
+	s = titleWords(s)
+
+	if s == "" {
+		return s
+	}
+
+	return strings.Title(s[:1]) + s[1:]
+}
+
+// Returns a file name without its extension and with separators ("-" and
+// "_") replaced by spaces.
+func titleWords(s string) string {
 	s = removeKnownExtension(s)
 
 	re, _ := regexp.Compile("[-_]")
-	s = re.ReplaceAllString(s, " ")
+	return re.ReplaceAllString(s, " ")
+}
 
-	return strings.Title(s[:1]) + s[1:]
+var orderPrefixPattern = regexp.MustCompile(`^\d+[-_.]`)
+
+// OrderPrefixPattern compiled, re is nil if the pattern is invalid.
+type orderPrefix struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// Returns OrderPrefixPattern compiled, or the default pattern if empty. It's
+// compiled once (and logged once if invalid) until the pattern changes,
+// returns nil if it's invalid.
+func (b *Builder) orderPrefixRegexp() *regexp.Regexp {
+	if b.OrderPrefixPattern == "" {
+		return orderPrefixPattern
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.orderPrefix == nil || b.orderPrefix.pattern != b.OrderPrefixPattern {
+		re, err := regexp.Compile(b.OrderPrefixPattern)
+		if err != nil {
+			Logger.Printf("Could not compile order prefix pattern %q: %s\n", b.OrderPrefixPattern, err.Error())
+		}
+		b.orderPrefix = &orderPrefix{pattern: b.OrderPrefixPattern, re: re}
+	}
+
+	return b.orderPrefix.re
+}
+
+// Returns the given file name without its ordering prefix, if
+// StripOrderPrefix is set.
+func (b *Builder) stripOrderPrefix(s string) string {
+	if b == nil || b.StripOrderPrefix == false {
+		return s
+	}
+
+	re := b.orderPrefixRegexp()
+
+	if re == nil {
+		return s
+	}
+
+	name := removeKnownExtension(s)
+
+	if stripped := re.ReplaceAllString(name, ""); stripped != "" {
+		return stripped + s[len(name):]
+	}
+
+	return s
+}
+
+// Like createTitle, the case of the name is kept if CapitalizeTitles is not
+// set, and ordering prefixes are left out with StripOrderPrefix.
+func (b *Builder) createTitle(s string) string {
+	s = b.stripOrderPrefix(b.pageName(s))
+
+	if b == nil || b.CapitalizeTitles {
+		return createTitle(s)
+	}
+	return titleWords(s)
 }
 
 // Returns a link.
 func (p *Page) CreateLink(file os.FileInfo, prefix string) map[string]interface{} {
 	item := map[string]interface{}{}
 
-	if file.IsDir() == true {
-		item["link"] = prefix + file.Name() + "/"
-	} else {
-		item["link"] = prefix + removeKnownExtension(file.Name())
-	}
+	item["link"] = p.builder.linkFor(file.Name(), file.IsDir(), prefix)
+
+	item["text"] = p.builder.createTitle(file.Name())
 
-	item["text"] = createTitle(file.Name())
+	item["weight"] = 0
 
 	return item
 }
 
 func (p *Page) CreateMenu() {
+	p.createMenu()
+	p.createTopMenu()
+}
+
+// Populates Page.Menu only, from the cache if it's there.
+func (p *Page) createMenu() {
 	var item map[string]interface{}
+
+	cacheKey := p.FileDir + p.BasePath
+
+	if p.builder != nil {
+		if menu, ok := p.builder.cachedMenu(cacheKey); ok {
+			p.trace(p.BasePath, "cached", len(menu))
+			p.Menu = menu
+			return
+		}
+	}
+
 	p.Menu = []map[string]interface{}{}
 
-	fmt.Printf("Creating menu...\n")
-	files := filterList(p.FileDir, directoryFilter)
-	fmt.Printf("done building files (%d entries)\n", len(files))
+	deps := newDependencies()
+
+	Logger.Printf("Creating menu...\n")
+	files, _ := p.listDirectory()
+	deps.list(p.FileDir, files)
+	Logger.Printf("done building files (%d entries)\n", len(files))
+	p.trace(p.BasePath, "list", len(files))
 
 	for _, file := range files {
 		item = p.CreateLink(file, p.BasePath)
-		fmt.Printf("Considering [%s]\n", p.FileDir+PS+file.Name())
-		children := filterList(p.FileDir+PS+file.Name(), 
+		p.builder.applySection(item, p.FileDir+PS+file.Name())
+		Logger.Printf("Considering [%s]\n", p.FileDir+PS+file.Name())
+		children := p.builder.filterList(p.FileDir+PS+file.Name(), 
 			directoryFilter)
-		fmt.Printf("   found %d children\n", len(children))
+		deps.list(p.FileDir+PS+file.Name(), children)
+		Logger.Printf("   found %d children\n", len(children))
+		p.trace(p.BasePath+file.Name()+"/", "children", len(children))
 		if len(children) > 0 {
 			item["children"] = []map[string]interface{}{}
 			for _, child := range children {
-				fmt.Printf("   matched [%s]\n", child)
+				Logger.Printf("   matched [%s]\n", child.Name())
+				p.trace(p.BasePath+file.Name()+"/"+child.Name()+"/", "child", 0)
 				childItem := p.CreateLink(child, p.BasePath+file.Name()+"/")
+				p.builder.applySection(childItem, p.FileDir+PS+file.Name()+PS+child.Name())
+				deps.readFrom(p.FileDir + PS + file.Name() + PS + child.Name())
 				item["children"] = append(item["children"].([]map[string]interface{}), childItem)
 			}
+			item["children"] = p.builder.limitItems(item["children"].([]map[string]interface{}), item["link"].(string))
 		}
 		p.Menu = append(p.Menu, item)
 	}
+
+	p.Menu = p.builder.limitItems(p.Menu, p.builder.styleLink(p.BasePath))
+
+	if p.builder != nil {
+		p.builder.cacheMenu(cacheKey, p.Menu, deps)
+	}
+}
+
+// Returns the menu of dir (relative to the content root), as CreateMenu builds
+// it for pages within it: its subdirectories with theirs as children. Menus
+// of the root's subdirectories are the branches of the site's menu.
+func (b *Builder) MenuSubtree(dir string) ([]map[string]interface{}, error) {
+	rel := strings.Trim(path.Clean("/"+dir), "/")
+
+	directory := strings.TrimRight(b.Root+PS+rel, PS)
+
+	if b.isListable(directory) == false {
+		return nil, fmt.Errorf("Could not build the menu of %s: not a directory.", dir)
+	}
+
+	p := b.NewPage(directory + PS + "index")
+
+	p.CreateMenu()
+
+	return p.Menu, nil
+}
+
+// Tells whether the page is the index of its directory.
+func (p *Page) isIndex() bool {
+	return p.IsNotFound == false && (p.shadows || removeKnownExtension(path.Base(p.FilePath)) == "index")
+}
+
+// Returns a link without its query string, fragment and trailing slash, for
+// comparing links.
+func normalizeLink(link string) string {
+	if i := strings.IndexAny(link, "?#"); i >= 0 {
+		link = link[:i]
+	}
+	if link = strings.TrimRight(link, "/"); link == "" {
+		return "/"
+	}
+	return link
+}
+
+// Tells whether link (i.e: "/guide/?x=1" or "/guide#intro") leads to the
+// current page.
+func (p *Page) IsActive(link string) bool {
+	return normalizeLink(link) == normalizeLink(p.Link)
+}
+
+// Sets "active" to true on the entries of a menu, or of its children, that
+// lead to the current page.
+func (p *Page) markActive(items []map[string]interface{}) {
+	for _, item := range items {
+		if link, ok := item["link"].(string); ok && p.IsActive(link) {
+			item["active"] = true
+		}
+		if children, ok := item["children"].([]map[string]interface{}); ok {
+			p.markActive(children)
+		}
+	}
 }
 
-// Populates Page.BreadCrumb with links.
+// Populates Page.TopMenu and sets "active_section" to true on the entry of
+// the page's ActiveSection (or of its subsection, with SectionTopMenu), and
+// "active" on entries leading to the page itself. Menus are shared by every
+// page of a directory, so this is done after caching them.
+func (p *Page) createTopMenu() {
+	top := "/"
+
+	if p.builder != nil && p.builder.SectionTopMenu && p.ActiveSection != "" {
+		top = "/" + p.ActiveSection + "/"
+	}
+
+	if p.BasePath == top || p.builder == nil {
+		p.TopMenu = p.Menu
+	} else {
+		// Just the menu, not the top menu of a page that's never shown.
+		root := p.builder.NewPage(p.builder.Root + strings.TrimRight(top, "/") + PS + "index")
+		root.createMenu()
+		p.TopMenu = root.Menu
+	}
+
+	p.markActive(p.Menu)
+	p.markActive(p.TopMenu)
+
+	section := p.ActiveSection
+
+	if top != "/" {
+		chunks := strings.Split(strings.Trim(p.BasePath, PS), PS)
+		if len(chunks) < 2 {
+			return
+		}
+		section = chunks[1]
+	}
+
+	if section == "" {
+		return
+	}
+
+	link := p.builder.linkFor(section, true, top)
+
+	for _, item := range p.TopMenu {
+		if normalizeLink(item["link"].(string)) == normalizeLink(link) {
+			item["active_section"] = true
+		}
+	}
+}
+
+// Populates Page.BreadCrumb with links, crumbs are named like their menu
+// items (after the directory's _section.yaml title, if any), except for the
+// last crumb of a directory index, named after the page's title. The home
+// page gets a single Home crumb, with no link and "current" set to true.
 func (p *Page) CreateBreadCrumb() {
 
-	p.BreadCrumb = []map[string]interface{}{
+	if p.Link == "/" && p.IsNotFound == false {
+		p.CurrentPage = map[string]interface{}{
+			"text":    "Home",
+			"current": true,
+		}
+		p.BreadCrumb = []map[string]interface{}{p.CurrentPage}
+		return
+	}
+
+	p.BreadCrumb = p.builder.BreadCrumbFor(p.BasePath)
+
+	if len(p.BreadCrumb) > 1 {
+		p.CurrentPage = p.BreadCrumb[len(p.BreadCrumb)-1]
+	}
+
+	if p.isIndex() && p.Title != "" && len(p.BreadCrumb) > 1 {
+		p.CurrentPage["text"] = p.Title
+	}
+
+}
+
+// Returns the breadcrumb of the given directory (i.e: "/guide/basics/"),
+// served or not: a Home crumb followed by one for each directory leading to
+// it, named like their menu items.
+func (b *Builder) BreadCrumbFor(basePath string) []map[string]interface{} {
+	crumbs := []map[string]interface{}{
 		map[string]interface{}{
 			"link": "/",
 			"text": "Home",
 		},
 	}
 
-	chunks := strings.Split(strings.Trim(p.BasePath, "/"), "/")
-
 	prefix := ""
 
-	for _, chunk := range chunks {
+	for _, chunk := range strings.Split(strings.Trim(basePath, "/"), "/") {
 		if chunk != "" {
 			item := map[string]interface{}{}
-			item["link"] = prefix + "/" + chunk + "/"
-			item["text"] = createTitle(chunk)
+			item["link"] = b.styleLink(prefix + "/" + chunk + "/")
+			item["text"] = b.createTitle(chunk)
 			prefix = prefix + PS + chunk
-			p.BreadCrumb = append(p.BreadCrumb, item)
-			p.CurrentPage = item
+			if b != nil {
+				b.applySection(item, b.Root+prefix)
+			}
+			crumbs = append(crumbs, item)
+		}
+	}
+
+	return crumbs
+}
+
+// Populates Page.Parent with the directory that contains the current page (or
+// the one above it, for directory indexes).
+func (p *Page) CreateParent() {
+	p.Parent = nil
+
+	link := strings.TrimRight(p.Link, "/")
+
+	if link == "" {
+		return
+	}
+
+	dir := path.Dir(link)
+
+	if dir == "/" || dir == "." {
+		p.Parent = map[string]interface{}{
+			"link": "/",
+			"text": "Home",
+		}
+		return
+	}
+
+	p.Parent = map[string]interface{}{
+		"link": p.builder.styleLink(dir + "/"),
+		"text": p.builder.createTitle(path.Base(dir)),
+	}
+
+	if p.builder != nil {
+		p.builder.applySection(p.Parent, p.builder.Root+dir)
+	}
+}
+
+// Returns the pages of a directory in reading order: its index first, then
+// its pages in side menu order and, if deep is true, the pages of its
+// subdirectories, depth first. Cached along with the menus.
+func (b *Builder) readingOrder(directory string, deep bool) []map[string]interface{} {
+	directory = strings.TrimRight(directory, PS)
+
+	cacheKey := directory + PS + "\x00reading order"
+	if deep {
+		cacheKey += " (deep)"
+	}
+
+	if items, ok := b.cachedMenu(cacheKey); ok {
+		return items
+	}
+
+	deps := newDependencies()
+
+	items := b.collectReadingOrder(directory, deep, deps)
+
+	b.cacheMenu(cacheKey, items, deps)
+
+	return items
+}
+
+func (b *Builder) collectReadingOrder(directory string, deep bool, deps *dependencies) []map[string]interface{} {
+	items := []map[string]interface{}{}
+
+	deps.readFrom(directory)
+	deps.expireAt(b.upcoming(directory))
+
+	if file, found := b.findIndex(directory); found && b.isPublishedFile(file) {
+		item := map[string]interface{}{
+			"link": b.NewPage(directory + PS + "index").Link,
+			"text": b.fileTitle(b.relPath(directory) + "/index"),
+		}
+		b.applySection(item, directory)
+		items = append(items, item)
+	}
+
+	pages, _ := b.siblings(directory)
+
+	items = append(items, pages...)
+
+	if deep {
+		dirs, _ := splitEntries(directory, b.readEntries(directory), pageFilter)
+		deps.list(directory, dirs)
+		for _, dir := range dirs {
+			items = append(items, b.collectReadingOrder(directory+PS+dir.Name(), true, deps)...)
+		}
+	}
+
+	return items
+}
+
+// Returns the entries of the side menu of a directory's index, with no
+// MaxItemsPerLevel limit, and the files they link to. Cached along with the
+// menus, so pages of the same directory don't list it again, until one of
+// them is published or expires.
+func (b *Builder) siblings(directory string) ([]map[string]interface{}, []string) {
+	directory = strings.TrimRight(directory, PS)
+
+	cacheKey := directory + PS + "\x00siblings"
+
+	if items, ok := b.cachedMenu(cacheKey); ok {
+		files := make([]string, len(items))
+		for i, item := range items {
+			files[i] = item["file"].(string)
+			delete(item, "file")
 		}
+		return items, files
 	}
 
+	items, files := b.NewPage(directory + PS + "index").sideMenuItems()
+
+	cached := copyMenu(items)
+	for i := range cached {
+		cached[i]["file"] = files[i]
+	}
+
+	deps := newDependencies()
+	deps.readFrom(directory)
+	deps.expireAt(b.upcoming(directory))
+
+	b.cacheMenu(cacheKey, cached, deps)
+
+	return items, files
 }
 
-// Populates Page.SideMenu with files on the current document's directory.
+// Populates Page.Prev and Page.Next with the pages around the current one on
+// its directory, or on the whole site if PrevNextAcrossSections is set (the
+// last page of a section is then followed by the first one of the next).
+func (p *Page) CreatePrevNext() {
+	p.Prev, p.Next = nil, nil
+
+	if p.builder == nil {
+		return
+	}
+
+	var items []map[string]interface{}
+
+	if p.builder.PrevNextAcrossSections {
+		items = p.builder.readingOrder(p.builder.Root, true)
+	} else {
+		items = p.builder.readingOrder(p.FileDir, false)
+	}
+
+	for i, item := range items {
+		if link, _ := item["link"].(string); p.IsActive(link) {
+			if i > 0 {
+				p.Prev = items[i-1]
+			}
+			if i < len(items)-1 {
+				p.Next = items[i+1]
+			}
+			return
+		}
+	}
+}
+
+// Populates Page.SiblingIndex and Page.SiblingCount.
+func (p *Page) CreateSiblingPosition() {
+	p.SiblingIndex, p.SiblingCount = -1, 0
+
+	if p.builder == nil {
+		return
+	}
+
+	_, files := p.builder.siblings(p.FileDir)
+
+	p.SiblingCount = len(files)
+
+	for i, file := range files {
+		if path.Clean(file) == path.Clean(p.FilePath) {
+			p.SiblingIndex = i
+			return
+		}
+	}
+}
+
+// Populates Page.SideMenu with files on the current document's directory, the
+// entry of the current document has "active" set to true.
 func (p *Page) CreateSideMenu() {
+	if p.isIndex() && p.builder.IndexShowsSideMenu == false {
+		p.SideMenu = []map[string]interface{}{}
+		return
+	}
+
+	p.SideMenu, _ = p.sideMenuItems()
+
+	p.SideMenu = p.builder.limitItems(p.SideMenu, p.builder.styleLink(p.BasePath))
+}
+
+// Returns the entries of the side menu, in order, with no MaxItemsPerLevel
+// limit, and the files they link to.
+func (p *Page) sideMenuItems() ([]map[string]interface{}, []string) {
 	var item map[string]interface{}
-	p.SideMenu = []map[string]interface{}{}
+	items := []map[string]interface{}{}
 
-	fmt.Printf("Creating side menu\n");
-	files := filterList(p.FileDir, mdFilter)
-	fmt.Printf("   done with %d entries\n", len(files));
+	Logger.Printf("Creating side menu\n")
+	_, files := p.listDirectory()
+	Logger.Printf("   done with %d entries\n", len(files))
 
 	for _, file := range files {
+		if strings.ToLower(removeKnownExtension(file.Name())) == "index" || p.builder.isHomeDocument(p.FileDir+file.Name()) {
+			continue
+		}
+		// Already on the menu, as the directory it's the index of.
+		if isShadowed(p.FileDir, file.Name()) {
+			continue
+		}
+		current := path.Clean(p.FileDir+file.Name()) == path.Clean(p.FilePath)
+		if current && p.builder.SideMenuExcludeCurrent {
+			continue
+		}
+		meta, err := p.builder.readMeta(p.builder.source(p.FileDir + file.Name()))
+		if err == nil && p.builder.isPublished(meta) == false {
+			continue
+		}
+		if p.builder.isMenuFile(p.FileDir, file.Name(), meta) == false {
+			continue
+		}
+		keep := p.builder.keepsExtension(p.FileDir, file.Name(), meta)
 		item = p.CreateLink(file, p.BasePath)
-		if strings.ToLower(item["text"].(string)) != "index" {
-			p.SideMenu = append(p.SideMenu, item)
+		if p.builder.applySlug(item, p.FileDir, file.Name(), meta, p.BasePath) == false {
+			continue
+		}
+		if keep {
+			item["link"] = p.BasePath + file.Name()
 		}
+		if current {
+			item["active"] = true
+		}
+		if err == nil {
+			applyMenuTitle(item, meta)
+			applyIcon(item, meta)
+			p.builder.applyDate(item, meta)
+			item["weight"] = metaInt(meta, "weight")
+		}
+		// Only kept until the items are sorted.
+		item["file"] = p.FileDir + file.Name()
+		items = append(items, item)
+	}
+
+	sortItems(items, loadSection(p.FileDir))
+
+	linked := make([]string, len(items))
+
+	for i, item := range items {
+		linked[i] = item["file"].(string)
+		delete(item, "file")
 	}
+
+	return items, linked
+}
+
+// Cuts the items of a menu level down to MaxItemsPerLevel, if set, and
+// appends a "more…" entry (with "more" set to true) after them, linking to
+// link, the directory the items are listed from. A cut level has
+// MaxItemsPerLevel items plus the "more…" entry.
+func (b *Builder) limitItems(items []map[string]interface{}, link string) []map[string]interface{} {
+	if b == nil || b.MaxItemsPerLevel <= 0 || len(items) <= b.MaxItemsPerLevel {
+		return items
+	}
+
+	more := map[string]interface{}{
+		"link":   link,
+		"text":   "more…",
+		"more":   true,
+		"weight": 0,
+	}
+
+	return append(items[:b.MaxItemsPerLevel:b.MaxItemsPerLevel], more)
 }
@@ -0,0 +1,630 @@
+package page
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A directory entry whose file was removed after the directory was read.
+type vanishedEntry struct {
+	name string
+}
+
+func (e vanishedEntry) Name() string               { return e.name }
+func (e vanishedEntry) IsDir() bool                { return false }
+func (e vanishedEntry) Type() os.FileMode          { return 0 }
+func (e vanishedEntry) Info() (os.FileInfo, error) { return nil, errors.New("file does not exist") }
+
+func TestFilterEntriesSkipsVanishedFiles(t *testing.T) {
+	root := fixture(t, map[string]string{
+		"a.md": "# A",
+		"c.md": "# C",
+	})
+
+	fp, err := os.Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := fp.ReadDir(-1)
+	fp.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ls = append(ls, vanishedEntry{"b.md"})
+
+	list := filterEntries(root, ls, pageFilter)
+
+	if len(list) != 2 || list[0].Name() != "a.md" || list[1].Name() != "c.md" {
+		t.Fatalf("Expecting a.md and c.md, got %v", list)
+	}
+}
+
+func TestFilterListMissingDirectory(t *testing.T) {
+	root := fixture(t, map[string]string{})
+
+	if list := filterList(root+PS+"removed", directoryFilter); len(list) != 0 {
+		t.Fatalf("Expecting an empty list, got %v", list)
+	}
+}
+
+func TestCreateParent(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":          "# Home",
+		"about.md":          "# About",
+		"guide/index.md":    "# Guide",
+		"guide/basics/a.md": "# A",
+	})
+
+	tests := map[string]map[string]interface{}{
+		"guide/basics/a.md": {"link": "/guide/basics/", "text": "Basics"},
+		"guide/index.md":    {"link": "/", "text": "Home"},
+		"about.md":          {"link": "/", "text": "Home"},
+		"index.md":          nil,
+	}
+
+	for file, expected := range tests {
+		p := b.NewPage(b.Root + PS + file)
+		p.CreateParent()
+		if reflect.DeepEqual(p.Parent, expected) == false {
+			t.Fatalf("%s: expecting parent %v, got %v", file, expected, p.Parent)
+		}
+	}
+}
+
+func TestCreateMenuTrace(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":              "# Home",
+		"api/index.md":          "# API",
+		"guide/basics/index.md": "# Basics",
+		"guide/extra/index.md":  "# Extra",
+	})
+
+	p := b.NewPage(b.Root + PS + "index.md")
+
+	events := p.CreateMenuTrace()
+
+	expected := []TraceEvent{
+		{"/", "list", 2},
+		{"/api/", "children", 0},
+		{"/guide/", "children", 2},
+		{"/guide/basics/", "child", 0},
+		{"/guide/extra/", "child", 0},
+	}
+
+	if reflect.DeepEqual(events, expected) == false {
+		t.Fatalf("Expecting %v, got %v", expected, events)
+	}
+
+	events = p.CreateMenuTrace()
+
+	if reflect.DeepEqual(events, []TraceEvent{{"/", "cached", 2}}) == false {
+		t.Fatalf("Expecting the menu to come from the cache, got %v", events)
+	}
+}
+
+func TestCreateBreadCrumbSectionTitles(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":                    "# Home",
+		"api-v2/_section.yaml":        "title: API v2\n",
+		"api-v2/getting-started/a.md": "# A",
+	})
+
+	p := b.NewPage(b.Root + PS + "api-v2/getting-started/a.md")
+	p.CreateBreadCrumb()
+
+	texts := []interface{}{}
+	for _, crumb := range p.BreadCrumb {
+		texts = append(texts, crumb["text"])
+	}
+
+	if reflect.DeepEqual(texts, []interface{}{"Home", "API v2", "Getting started"}) == false {
+		t.Fatalf("Unexpected breadcrumb %v", texts)
+	}
+}
+
+func TestBreadCrumbFor(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":                    "# Home",
+		"api-v2/_section.yaml":        "title: API v2\n",
+		"api-v2/getting-started/a.md": "# A",
+	})
+
+	p := b.NewPage(b.Root + PS + "api-v2/getting-started/a.md")
+	p.CreateBreadCrumb()
+
+	if crumbs := b.BreadCrumbFor(p.BasePath); reflect.DeepEqual(crumbs, p.BreadCrumb) == false {
+		t.Fatalf("Expecting the trail of the page, %v, got %v", p.BreadCrumb, crumbs)
+	}
+
+	texts := []interface{}{}
+	for _, crumb := range b.BreadCrumbFor("/api-v2/not-served/") {
+		texts = append(texts, crumb["text"])
+	}
+
+	if reflect.DeepEqual(texts, []interface{}{"Home", "API v2", "Not served"}) == false {
+		t.Fatalf("Unexpected breadcrumb %v", texts)
+	}
+}
+
+func TestStripOrderPrefix(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"01-basics/01-intro.md": "Intro",
+		"01-basics/02-setup.md": "Setup",
+		"01-basics/2013.md":     "Year",
+	})
+
+	b.StripOrderPrefix = true
+
+	p := b.NewPage(b.Root + PS + "01-basics/01-intro.md")
+	p.CreateBreadCrumb()
+	p.CreateSideMenu()
+
+	items := []interface{}{}
+	for _, item := range p.SideMenu {
+		items = append(items, item["text"], item["link"])
+	}
+
+	expected := []interface{}{"Intro", "/01-basics/01-intro", "Setup", "/01-basics/02-setup", "2013", "/01-basics/2013"}
+
+	if reflect.DeepEqual(items, expected) == false {
+		t.Fatalf("Expecting %v, got %v", expected, items)
+	}
+
+	if crumb := p.BreadCrumb[1]; crumb["text"] != "Basics" || crumb["link"] != "/01-basics/" {
+		t.Fatalf("Expecting the directory's prefix to be stripped too, got %v", crumb)
+	}
+
+	b.OrderPrefixPattern = `^\d+-\d+-`
+	b.StripOrderPrefix = false
+
+	if title := b.createTitle("01-02-intro.md"); title != "01 02 intro" {
+		t.Fatalf("Expecting nothing stripped unless StripOrderPrefix is set, got %q", title)
+	}
+
+	b.StripOrderPrefix = true
+
+	if title := b.createTitle("01-02-intro.md"); title != "Intro" {
+		t.Fatalf("Expecting the configured pattern to be stripped, got %q", title)
+	}
+
+	compiled := b.orderPrefix
+
+	b.createTitle("03-04-setup.md")
+
+	if b.orderPrefix != compiled {
+		t.Fatalf("Expecting the pattern to be compiled only once")
+	}
+
+	var logged bytes.Buffer
+	defer func(l *log.Logger) { Logger = l }(Logger)
+	Logger = log.New(&logged, "", 0)
+
+	b.OrderPrefixPattern = `^(\d+-`
+
+	for i := 0; i < 2; i++ {
+		if title := b.createTitle("01-intro.md"); title != "01 intro" {
+			t.Fatalf("Expecting an invalid pattern to strip nothing, got %q", title)
+		}
+	}
+
+	if strings.Count(logged.String(), "order prefix pattern") != 1 {
+		t.Fatalf("Expecting the invalid pattern to be logged once, got %q", logged.String())
+	}
+}
+
+func TestCreateSideMenuCurrent(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md": "# Guide",
+		"guide/intro.md": "# Intro",
+		"guide/setup.md": "# Setup",
+	})
+
+	p := b.NewPage(b.Root + PS + "guide/intro.md")
+	p.CreateSideMenu()
+
+	active := []interface{}{}
+	for _, item := range p.SideMenu {
+		active = append(active, item["active"])
+	}
+
+	if reflect.DeepEqual(active, []interface{}{true, nil}) == false {
+		t.Fatalf("Expecting only the current page to be active, got %v", p.SideMenu)
+	}
+
+	b.SideMenuExcludeCurrent = true
+	p.CreateSideMenu()
+
+	if len(p.SideMenu) != 1 || p.SideMenu[0]["link"] != "/guide/setup" {
+		t.Fatalf("Expecting the current page to be left out, got %v", p.SideMenu)
+	}
+}
+
+func TestCreateBreadCrumbHome(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md": "# Home",
+		"about.md": "# About",
+	})
+
+	p, err := b.Build(b.Root + PS + "index.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.IsHome == false || len(p.BreadCrumb) != 1 {
+		t.Fatalf("Expecting a single crumb on the home page, got %v", p.BreadCrumb)
+	}
+
+	if p.BreadCrumb[0]["current"] != true || p.BreadCrumb[0]["link"] != nil || p.CurrentPage["text"] != "Home" {
+		t.Fatalf("Expecting a current, non-linked Home crumb, got %v", p.BreadCrumb[0])
+	}
+
+	p, err = b.Build(b.Root + PS + "about.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.BreadCrumb) != 1 || p.BreadCrumb[0]["link"] != "/" {
+		t.Fatalf("Expecting a linked Home crumb elsewhere, got %v", p.BreadCrumb)
+	}
+}
+
+func TestListDirectory(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md":      "# Guide",
+		"guide/intro.md":      "# Intro",
+		"guide/table.html":    "<h1>Table</h1>",
+		"guide/_header.md":    "Header",
+		"guide/basics/one.md": "# One",
+		"guide/_hidden/a.md":  "# A",
+		"guide/zeta/b.md":     "# B",
+	})
+
+	dir := b.Root + PS + "guide"
+
+	dirs, files := b.NewPage(dir + PS + "intro.md").listDirectory()
+
+	if reflect.DeepEqual(dirs, b.filterList(dir, directoryFilter)) == false {
+		t.Fatalf("Unexpected directories %v", dirs)
+	}
+
+	if reflect.DeepEqual(files, b.filterList(dir, b.pageFilter)) == false {
+		t.Fatalf("Unexpected files %v", files)
+	}
+
+	reads := map[string]int{}
+
+	defer func(original func(string) []os.DirEntry) { readEntries = original }(readEntries)
+
+	original := readEntries
+	readEntries = func(directory string) []os.DirEntry {
+		reads[directory]++
+		return original(directory)
+	}
+
+	p := b.NewPage(dir + PS + "intro.md")
+	p.CreateMenu()
+	p.CreateSideMenu()
+
+	if reads[p.FileDir] != 1 {
+		t.Fatalf("Expecting %s to be read once, got %d", p.FileDir, reads[p.FileDir])
+	}
+
+	if len(p.Menu) != 2 || len(p.SideMenu) != 1 {
+		t.Fatalf("Unexpected menus %v and %v", p.Menu, p.SideMenu)
+	}
+}
+
+func TestKeepExtension(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md":        "# Guide",
+		"guide/intro.md":        "# Intro",
+		"guide/demo.html":       "---\nkeep_extension: true\n---\n<h1>Demo</h1>",
+		"guide/table.html":      "<h1>Table</h1>",
+		"samples/_section.yaml": "keep_extension: true\n",
+		"samples/chart.html":    "<h1>Chart</h1>",
+		"samples/notes.md":      "# Notes",
+	})
+
+	links := func(file string) []interface{} {
+		p := b.NewPage(b.Root + PS + file)
+		p.CreateSideMenu()
+		out := []interface{}{}
+		for _, item := range p.SideMenu {
+			out = append(out, item["link"])
+		}
+		return out
+	}
+
+	if l := links("guide/intro.md"); reflect.DeepEqual(l, []interface{}{"/guide/demo.html", "/guide/intro"}) == false {
+		t.Fatalf("Expecting demo.html to keep its extension, got %v", l)
+	}
+
+	if l := links("samples/notes.md"); reflect.DeepEqual(l, []interface{}{"/samples/chart.html", "/samples/notes"}) == false {
+		t.Fatalf("Expecting the directory policy to keep the extensions of non-pages only, got %v", l)
+	}
+
+	for rel, expected := range map[string]string{"guide/demo.html": "/guide/demo.html", "samples/notes.md": "/samples/notes"} {
+		if url, err := b.URLByPath(rel); err != nil || url != expected {
+			t.Fatalf("Expecting %s, got %q (%v)", expected, url, err)
+		}
+	}
+
+	if src, kept := b.KeptSource(b.Root + PS + "guide/demo.html"); kept == false || string(src) != "<h1>Demo</h1>" {
+		t.Fatalf("Expecting demo.html to be served without its front matter, got %q", src)
+	}
+
+	if _, kept := b.KeptSource(b.Root + PS + "guide/table.html"); kept {
+		t.Fatalf("Expecting table.html to be served as it is.")
+	}
+}
+
+func TestActiveSection(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":                 "# Home",
+		"api/index.md":             "# API",
+		"guide/index.md":           "# Guide",
+		"guide/basics/deeper/a.md": "# A",
+	})
+
+	flagged := func(file string) (string, []interface{}) {
+		p := b.NewPage(b.Root + PS + file)
+		p.CreateMenu()
+		out := []interface{}{}
+		for _, item := range p.TopMenu {
+			if item["active_section"] == true {
+				out = append(out, item["link"])
+			}
+		}
+		return p.ActiveSection, out
+	}
+
+	for i := 0; i < 2; i++ {
+		// The second time around menus come from the cache.
+		section, links := flagged("guide/basics/deeper/a.md")
+		if section != "guide" || reflect.DeepEqual(links, []interface{}{"/guide/"}) == false {
+			t.Fatalf("Expecting the guide entry to be flagged, got %q and %v", section, links)
+		}
+
+		section, links = flagged("index.md")
+		if section != "" || len(links) != 0 {
+			t.Fatalf("Expecting nothing to be flagged on the home page, got %q and %v", section, links)
+		}
+	}
+}
+
+func TestSectionTopMenu(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":                  "# Home",
+		"product-a/index.md":        "# A",
+		"product-a/install/page.md": "# Install",
+		"product-a/usage/page.md":   "# Usage",
+		"product-b/index.md":        "# B",
+		"product-b/api/page.md":     "# API",
+	})
+
+	links := func(file string) ([]interface{}, []interface{}) {
+		p := b.NewPage(b.Root + PS + file)
+		p.CreateMenu()
+		out, flagged := []interface{}{}, []interface{}{}
+		for _, item := range p.TopMenu {
+			out = append(out, item["link"])
+			if item["active_section"] == true {
+				flagged = append(flagged, item["link"])
+			}
+		}
+		return out, flagged
+	}
+
+	b.SectionTopMenu = true
+
+	for i := 0; i < 2; i++ {
+		// The second time around menus come from the cache.
+		menu, flagged := links("product-a/install/page.md")
+		if reflect.DeepEqual(menu, []interface{}{"/product-a/install/", "/product-a/usage/"}) == false {
+			t.Fatalf("Expecting only the sections of product A, got %v", menu)
+		}
+		if reflect.DeepEqual(flagged, []interface{}{"/product-a/install/"}) == false {
+			t.Fatalf("Expecting the install entry to be flagged, got %v", flagged)
+		}
+
+		menu, _ = links("product-a/index.md")
+		if reflect.DeepEqual(menu, []interface{}{"/product-a/install/", "/product-a/usage/"}) == false {
+			t.Fatalf("Expecting only the sections of product A on its index, got %v", menu)
+		}
+
+		menu, _ = links("index.md")
+		if reflect.DeepEqual(menu, []interface{}{"/product-a/", "/product-b/"}) == false {
+			t.Fatalf("Expecting the root menu on the home page, got %v", menu)
+		}
+	}
+}
+
+func TestMaxItemsPerLevel(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"big/a.md":     "# A",
+		"big/b.md":     "# B",
+		"big/c.md":     "# C",
+		"big/d.md":     "# D",
+		"small/a.md":   "# A",
+		"small/b/x.md": "# X",
+		"small/c/x.md": "# X",
+	})
+
+	b.MaxItemsPerLevel = 2
+
+	links := func(items []map[string]interface{}) []interface{} {
+		out := []interface{}{}
+		for _, item := range items {
+			out = append(out, item["link"])
+		}
+		return out
+	}
+
+	p := b.NewPage(b.Root + PS + "big/a.md")
+	p.CreateSideMenu()
+
+	if l := links(p.SideMenu); reflect.DeepEqual(l, []interface{}{"/big/a", "/big/b", "/big/"}) == false {
+		t.Fatalf("Expecting two entries and a more link, got %v", l)
+	}
+
+	// A level of exactly MaxItemsPerLevel entries is not cut.
+	b.MaxItemsPerLevel = 4
+
+	p = b.NewPage(b.Root + PS + "big/a.md")
+	p.CreateSideMenu()
+
+	if l := links(p.SideMenu); reflect.DeepEqual(l, []interface{}{"/big/a", "/big/b", "/big/c", "/big/d"}) == false {
+		t.Fatalf("Expecting all four entries, got %v", l)
+	}
+
+	b.MaxItemsPerLevel = 2
+	p = b.NewPage(b.Root + PS + "big/a.md")
+	p.CreateSideMenu()
+
+	if p.SideMenu[2]["more"] != true || p.SideMenu[2]["text"] != "more…" {
+		t.Fatalf("Expecting a more… entry, got %v", p.SideMenu[2])
+	}
+
+	p = b.NewPage(b.Root + PS + "small/a.md")
+	p.CreateSideMenu()
+	p.CreateMenu()
+
+	if l := links(p.SideMenu); reflect.DeepEqual(l, []interface{}{"/small/a"}) == false {
+		t.Fatalf("Expecting the full side menu, got %v", l)
+	}
+
+	if l := links(p.Menu); reflect.DeepEqual(l, []interface{}{"/small/b/", "/small/c/"}) == false {
+		t.Fatalf("Expecting the full menu, got %v", l)
+	}
+}
+
+func TestIsActive(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":       "# Home",
+		"api/index.md":   "# API",
+		"guide/index.md": "# Guide",
+	})
+
+	p, err := b.Build(b.Root + PS + "guide/index.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, link := range []string{"/guide/?x=1", "/guide/#intro", "/guide?x=1#intro", "/guide/"} {
+		if p.IsActive(link) == false {
+			t.Fatalf("Expecting %s to lead to %s", link, p.Link)
+		}
+	}
+
+	if p.IsActive("/api/?x=1") || p.IsActive("/guide/more") {
+		t.Fatalf("Expecting other links not to be active")
+	}
+
+	active := []interface{}{}
+	for _, item := range p.TopMenu {
+		if item["active"] == true {
+			active = append(active, item["link"])
+		}
+	}
+
+	if reflect.DeepEqual(active, []interface{}{"/guide/"}) == false {
+		t.Fatalf("Expecting the guide entry to be active, got %v", active)
+	}
+}
+
+func TestIndexShowsSideMenu(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/_section.yaml": "title: Section title\n",
+		"guide/index.md":      "# The Guide\n",
+		"guide/intro.md":      "# Intro",
+		"guide/setup.md":      "# Setup",
+	})
+
+	build := func(file string) *Page {
+		p, err := b.Build(b.Root + PS + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	p := build("guide/index.md")
+
+	if last := p.BreadCrumb[len(p.BreadCrumb)-1]; last["text"] != "The Guide" || last["link"] != "/guide/" {
+		t.Fatalf("Expecting the last crumb to be named after the index, got %v", last)
+	}
+
+	if len(p.SideMenu) != 2 {
+		t.Fatalf("Expecting the index to have a side menu by default, got %v", p.SideMenu)
+	}
+
+	b.IndexShowsSideMenu = false
+
+	if p = build("guide/index.md"); len(p.SideMenu) != 0 {
+		t.Fatalf("Expecting no side menu on the index, got %v", p.SideMenu)
+	}
+
+	p = build("guide/intro.md")
+
+	if len(p.SideMenu) != 2 {
+		t.Fatalf("Expecting other pages to keep their side menu, got %v", p.SideMenu)
+	}
+
+	if last := p.BreadCrumb[len(p.BreadCrumb)-1]; last["text"] != "Section title" {
+		t.Fatalf("Expecting the section's title on other pages, got %v", last)
+	}
+}
+
+func TestMenuSubtree(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":                 "# Home",
+		"guide/index.md":           "# Guide",
+		"guide/basics/index.md":    "# Basics",
+		"guide/basics/more/one.md": "# One",
+		"guide/advanced/index.md":  "---\ntitle: Going further\n---\n# Advanced",
+		"blog/index.md":            "# Blog",
+	})
+
+	p, err := b.Build(b.Root + PS + "index.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var branch []map[string]interface{}
+	for _, item := range p.Menu {
+		if item["link"] == "/guide/" {
+			branch, _ = item["children"].([]map[string]interface{})
+		}
+	}
+
+	subtree, err := b.MenuSubtree("guide")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(subtree) != len(branch) || len(subtree) != 2 {
+		t.Fatalf("Expecting the branch of the menu, got %v and %v", subtree, branch)
+	}
+
+	for i, item := range subtree {
+		if item["link"] != branch[i]["link"] || item["text"] != branch[i]["text"] {
+			t.Fatalf("Expecting %v, got %v", branch[i], item)
+		}
+	}
+
+	if subtree[1]["link"] != "/guide/basics/" || len(subtree[1]["children"].([]map[string]interface{})) != 1 {
+		t.Fatalf("Expecting the subtree to go one level deeper, got %v", subtree[1])
+	}
+
+	for _, dir := range []string{"nowhere", "guide/index.md"} {
+		if _, err := b.MenuSubtree(dir); err == nil {
+			t.Fatalf("Expecting %s to fail.", dir)
+		}
+	}
+}
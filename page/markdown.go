@@ -0,0 +1,320 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bytes"
+	"fmt"
+	md "github.com/russross/blackfriday"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// Markdown extensions that can be turned on and off, the defaults (see
+// NewBuilder) match md.MarkdownCommon.
+type MarkdownOptions struct {
+	// | Pipe | tables |
+	Tables bool
+	// ~~Struck through~~ text.
+	Strikethrough bool
+	// List items beginning with [ ] or [x] become checkboxes.
+	TaskLists bool
+	// Every newline within a paragraph is a line break.
+	HardLineBreaks bool
+	// Bare URLs become links.
+	Autolink bool
+	// Footnotes[^1] are gathered at the bottom, linking back to where
+	// they're referenced.
+	Footnotes bool
+	// Straight quotes become curly quotes, -- and --- en and em dashes and
+	// ... an ellipsis, everywhere but in code.
+	SmartTypography bool
+	// Terms followed by lines beginning with ": " become definition lists
+	// (<dl>).
+	DefinitionLists bool
+}
+
+// Extensions of md.MarkdownCommon that are always on.
+const markdownExtensions = md.EXTENSION_NO_INTRA_EMPHASIS |
+	md.EXTENSION_FENCED_CODE |
+	md.EXTENSION_SPACE_HEADERS |
+	md.EXTENSION_HEADER_IDS |
+	md.EXTENSION_BACKSLASH_LINE_BREAK
+
+const markdownHTMLFlags = md.HTML_USE_XHTML
+
+// HTML flags of md.MarkdownCommon turned on by SmartTypography.
+const smartTypographyFlags = md.HTML_USE_SMARTYPANTS |
+	md.HTML_SMARTYPANTS_FRACTIONS |
+	md.HTML_SMARTYPANTS_DASHES |
+	md.HTML_SMARTYPANTS_LATEX_DASHES
+
+var taskListPattern = regexp.MustCompile(`<li>(<p>)?\[([ xX])\]\s`)
+
+// An HTML renderer that numbers the lines of code blocks, each line is
+// wrapped in a <span class="line"> that begins with a
+// <span class="line-number">.
+type numberedCodeRenderer struct {
+	md.Renderer
+}
+
+func (r numberedCodeRenderer) BlockCode(out *bytes.Buffer, text []byte, infoString string) {
+	if out.Len() > 0 {
+		out.WriteByte('\n')
+	}
+
+	out.WriteString(`<pre class="line-numbers"><code`)
+
+	if fields := strings.Fields(infoString); len(fields) > 0 {
+		out.WriteString(` class="language-` + template.HTMLEscapeString(fields[0]) + `"`)
+	}
+
+	out.WriteString(">")
+
+	lines := strings.Split(strings.TrimSuffix(string(text), "\n"), "\n")
+
+	for i, line := range lines {
+		fmt.Fprintf(out, `<span class="line"><span class="line-number">%d</span>%s</span>`+"\n", i+1, template.HTMLEscapeString(line))
+	}
+
+	out.WriteString("</code></pre>\n")
+}
+
+// An HTML renderer that leaves fenced blocks of the given languages (i.e:
+// "mermaid") in a <div> of that class, for scripts to draw on the client.
+type diagramRenderer struct {
+	md.Renderer
+	languages []string
+}
+
+func (r diagramRenderer) BlockCode(out *bytes.Buffer, text []byte, infoString string) {
+	if fields := strings.Fields(infoString); len(fields) > 0 {
+		for _, language := range r.languages {
+			if fields[0] == language {
+				if out.Len() > 0 {
+					out.WriteByte('\n')
+				}
+				out.WriteString(`<div class="` + template.HTMLEscapeString(language) + `">` + template.HTMLEscapeString(string(text)) + "</div>\n")
+				return
+			}
+		}
+	}
+	r.Renderer.BlockCode(out, text, infoString)
+}
+
+// Renders markdown source as HTML, with the builder's Markdown options.
+func (b *Builder) markdown(src []byte) []byte {
+	extensions := markdownExtensions
+
+	if b.Markdown.Tables {
+		extensions |= md.EXTENSION_TABLES
+	}
+	if b.Markdown.Strikethrough {
+		extensions |= md.EXTENSION_STRIKETHROUGH
+	}
+	if b.Markdown.HardLineBreaks {
+		extensions |= md.EXTENSION_HARD_LINE_BREAK
+	}
+	if b.Markdown.Autolink {
+		extensions |= md.EXTENSION_AUTOLINK
+	}
+	if b.Markdown.DefinitionLists {
+		extensions |= md.EXTENSION_DEFINITION_LISTS
+	}
+
+	htmlFlags := markdownHTMLFlags
+
+	if b.Markdown.SmartTypography {
+		htmlFlags |= smartTypographyFlags
+	}
+	if b.Markdown.Footnotes {
+		extensions |= md.EXTENSION_FOOTNOTES
+		htmlFlags |= md.HTML_FOOTNOTE_RETURN_LINKS
+	}
+
+	renderer := md.HtmlRenderer(htmlFlags, "", "")
+
+	if b.CodeLineNumbers {
+		renderer = numberedCodeRenderer{renderer}
+	}
+
+	if len(b.DiagramLanguages) > 0 {
+		renderer = diagramRenderer{renderer, b.DiagramLanguages}
+	}
+
+	out := md.Markdown(src, renderer, extensions)
+
+	if b.Markdown.TaskLists {
+		out = taskListPattern.ReplaceAllFunc(out, func(item []byte) []byte {
+			m := taskListPattern.FindSubmatch(item)
+			checkbox := `<input type="checkbox" disabled="disabled" /> `
+			if string(m[2]) != " " {
+				checkbox = `<input type="checkbox" checked="checked" disabled="disabled" /> `
+			}
+			return []byte(`<li class="task">` + string(m[1]) + checkbox)
+		})
+	}
+
+	return out
+}
+
+// A line opening or closing a fenced code block, i.e: ```go or ~~~.
+var codeFencePattern = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+
+// Returns the offsets, as [start, end] pairs, of the code of markdown source:
+// its fenced and indented code blocks and its code spans. Directives expanded
+// on the source are left alone there, like the TOCMarker and emoji are on the
+// code of the HTML.
+func markdownCode(src []byte) [][]int {
+	code := [][]int{}
+
+	var fence []byte
+	fenceStart := 0
+
+	// Where the current paragraph starts, if there's one.
+	paragraph := -1
+
+	blank, indented := true, false
+
+	for offset, end := 0, 0; offset < len(src); offset = end {
+		end = len(src)
+		if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+			end = offset + i + 1
+		}
+
+		line := src[offset:end]
+		empty := len(bytes.TrimSpace(line)) == 0
+
+		if fence != nil {
+			m := codeFencePattern.FindSubmatch(line)
+			if m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence) && len(bytes.TrimSpace(line[len(m[0]):])) == 0 {
+				code = append(code, []int{fenceStart, end})
+				fence = nil
+				blank = true
+			}
+			continue
+		}
+
+		m := codeFencePattern.FindSubmatch(line)
+
+		// The info string of a backtick fence can't have backticks.
+		opens := m != nil && (m[1][0] == '~' || bytes.IndexByte(line[len(m[0]):], '`') < 0)
+
+		// Indented code can't interrupt a paragraph.
+		isIndented := empty == false && (blank || indented) &&
+			(bytes.HasPrefix(line, []byte("    ")) || line[0] == '\t')
+
+		if paragraph >= 0 && (empty || opens) {
+			code = append(code, codeSpans(src, paragraph, offset)...)
+			paragraph = -1
+		}
+
+		indented = false
+
+		switch {
+		case opens:
+			fence = m[1]
+			fenceStart = offset
+		case isIndented:
+			code = append(code, []int{offset, end})
+			indented = true
+		case empty:
+		default:
+			if paragraph < 0 {
+				paragraph = offset
+			}
+		}
+
+		blank = empty
+	}
+
+	if fence != nil {
+		// Unclosed fences run until the end.
+		code = append(code, []int{fenceStart, len(src)})
+	}
+
+	if paragraph >= 0 {
+		code = append(code, codeSpans(src, paragraph, len(src))...)
+	}
+
+	return code
+}
+
+// Returns the offsets of the code spans of src[start:end], a paragraph. A
+// span is closed by a run of as many backticks as the one it opens with.
+func codeSpans(src []byte, start int, end int) [][]int {
+	spans := [][]int{}
+
+	for i := start; i < end; {
+		n := backticks(src[i:end])
+		if n == 0 {
+			i++
+			continue
+		}
+
+		closing := -1
+		for j := i + n; j < end; {
+			m := backticks(src[j:end])
+			if m == n {
+				closing = j
+				break
+			}
+			if m == 0 {
+				m = 1
+			}
+			j += m
+		}
+
+		if closing < 0 {
+			// Backticks without a match are literal.
+			i += n
+			continue
+		}
+
+		spans = append(spans, []int{i, closing + n})
+		i = closing + n
+	}
+
+	return spans
+}
+
+// Returns the number of backticks s starts with.
+func backticks(s []byte) int {
+	n := 0
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	return n
+}
+
+// Tells whether the offset i is within one of the ranges of code.
+func inCode(code [][]int, i int) bool {
+	for _, r := range code {
+		if i >= r[0] && i < r[1] {
+			return true
+		}
+	}
+	return false
+}
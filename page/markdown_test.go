@@ -0,0 +1,181 @@
+package page
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownOptions(t *testing.T) {
+	src := []byte("| a | b |\n|---|---|\n| 1 | 2 |\n\n" +
+		"~~gone~~\n\n" +
+		"* [ ] todo\n* [x] done\n\n" +
+		"one\ntwo\n\n" +
+		"See http://example.org\n")
+
+	tests := []struct {
+		name     string
+		set      func(o *MarkdownOptions, on bool)
+		expected string
+	}{
+		{"tables", func(o *MarkdownOptions, on bool) { o.Tables = on }, "<table>"},
+		{"strikethrough", func(o *MarkdownOptions, on bool) { o.Strikethrough = on }, "<del>gone</del>"},
+		{"task lists", func(o *MarkdownOptions, on bool) { o.TaskLists = on }, `<li class="task"><input type="checkbox" checked="checked" disabled="disabled" /> done`},
+		{"hard line breaks", func(o *MarkdownOptions, on bool) { o.HardLineBreaks = on }, "one<br />\ntwo"},
+		{"autolink", func(o *MarkdownOptions, on bool) { o.Autolink = on }, `<a href="http://example.org">`},
+	}
+
+	for _, test := range tests {
+		b := &Builder{}
+
+		test.set(&b.Markdown, true)
+		if out := string(b.markdown(src)); strings.Contains(out, test.expected) == false {
+			t.Fatalf("%s on: expecting %q in %q", test.name, test.expected, out)
+		}
+
+		b.Markdown = MarkdownOptions{
+			Tables:          true,
+			Strikethrough:   true,
+			TaskLists:       true,
+			HardLineBreaks:  true,
+			Autolink:        true,
+			Footnotes:       true,
+			SmartTypography: true,
+			DefinitionLists: true,
+		}
+		test.set(&b.Markdown, false)
+		if out := string(b.markdown(src)); strings.Contains(out, test.expected) == true {
+			t.Fatalf("%s off: not expecting %q in %q", test.name, test.expected, out)
+		}
+	}
+}
+
+func TestCodeLineNumbers(t *testing.T) {
+	b := testBuilder(t, map[string]string{})
+
+	src := []byte("Run `go build` first.\n\n```go\nfunc main() {\n\tfmt.Println(\"<hi>\")\n}\n```\n")
+
+	plain := string(b.markdown(src))
+
+	if strings.Contains(plain, "line-number") || strings.Contains(plain, "<pre><code") == false {
+		t.Fatalf("Expecting the block to be left untouched, got %s", plain)
+	}
+
+	b.CodeLineNumbers = true
+
+	out := string(b.markdown(src))
+
+	expected := `<pre class="line-numbers"><code class="language-go">` +
+		`<span class="line"><span class="line-number">1</span>func main() {</span>` + "\n" +
+		`<span class="line"><span class="line-number">2</span>` + "\t" + `fmt.Println(&#34;&lt;hi&gt;&#34;)</span>` + "\n" +
+		`<span class="line"><span class="line-number">3</span>}</span>` + "\n" +
+		`</code></pre>`
+
+	if strings.Contains(out, expected) == false {
+		t.Fatalf("Expecting numbered lines, got %s", out)
+	}
+
+	if strings.Contains(out, "<code>go build</code>") == false {
+		t.Fatalf("Expecting inline code not to be numbered, got %s", out)
+	}
+}
+
+func TestDiagramLanguages(t *testing.T) {
+	b := testBuilder(t, map[string]string{})
+
+	src := []byte("```mermaid\ngraph TD;\n  A-->B;\n```\n\n```go\nfunc main() {}\n```\n")
+
+	if out := string(b.markdown(src)); strings.Contains(out, `<div class="mermaid">`) {
+		t.Fatalf("Expecting no diagrams unless configured, got %s", out)
+	}
+
+	b.DiagramLanguages = []string{"mermaid"}
+
+	out := string(b.markdown(src))
+
+	if strings.Contains(out, `<div class="mermaid">graph TD;`+"\n"+`  A--&gt;B;`+"\n"+`</div>`) == false {
+		t.Fatalf("Expecting the mermaid block in a container, got %s", out)
+	}
+
+	if strings.Contains(out, `<pre><code class="language-go">func main() {}`) == false {
+		t.Fatalf("Expecting other code blocks to be left alone, got %s", out)
+	}
+}
+
+func TestFootnotes(t *testing.T) {
+	b := testBuilder(t, map[string]string{})
+
+	src := []byte("Luminos[^name] serves markdown[^md].\n\n[^name]: From the Latin for light.\n[^md]: See daringfireball.net.\n")
+
+	if out := string(b.markdown(src)); strings.Contains(out, `class="footnotes"`) {
+		t.Fatalf("Expecting no footnotes unless enabled, got %s", out)
+	}
+
+	b.Markdown.Footnotes = true
+
+	out := string(b.markdown(src))
+
+	for _, expected := range []string{
+		`<sup class="footnote-ref" id="fnref:name"><a href="#fn:name">1</a></sup>`,
+		`<sup class="footnote-ref" id="fnref:md"><a href="#fn:md">2</a></sup>`,
+		`<div class="footnotes">`,
+		`<li id="fn:name">From the Latin for light.` + "\n" + ` <a class="footnote-return" href="#fnref:name">`,
+		`<li id="fn:md">See daringfireball.net.` + "\n" + ` <a class="footnote-return" href="#fnref:md">`,
+	} {
+		if strings.Contains(out, expected) == false {
+			t.Fatalf("Expecting %q in %s", expected, out)
+		}
+	}
+
+	if strings.Index(out, `id="fn:name"`) > strings.Index(out, `id="fn:md"`) || strings.Contains(out, "<ol>") == false {
+		t.Fatalf("Expecting a numbered list of footnotes, in order, got %s", out)
+	}
+}
+
+func TestSmartTypography(t *testing.T) {
+	b := testBuilder(t, map[string]string{})
+
+	src := []byte("He said \"wait\" -- then left --- for good...\n\n" +
+		"Run `say \"hi\" -- now...` first.\n\n" +
+		"```\necho \"hi\" -- now...\n```\n")
+
+	out := string(b.markdown(src))
+
+	for _, expected := range []string{"&ldquo;wait&rdquo;", "&ndash; then", "&mdash; for", "good&hellip;"} {
+		if strings.Contains(out, expected) == false {
+			t.Fatalf("Expecting %q in the prose of %s", expected, out)
+		}
+	}
+
+	if strings.Contains(out, "<code>say &quot;hi&quot; -- now...</code>") == false {
+		t.Fatalf("Expecting inline code to be left untouched, got %s", out)
+	}
+	if strings.Contains(out, "<pre><code>echo &quot;hi&quot; -- now...\n</code></pre>") == false {
+		t.Fatalf("Expecting fenced blocks to be left untouched, got %s", out)
+	}
+
+	b.Markdown.SmartTypography = false
+
+	if out := string(b.markdown(src)); strings.Contains(out, "&ldquo;") || strings.Contains(out, "&ndash;") {
+		t.Fatalf("Not expecting smart typography when it's off, got %s", out)
+	}
+}
+
+func TestDefinitionLists(t *testing.T) {
+	b := testBuilder(t, map[string]string{})
+
+	src := []byte("Glossary:\n\nBuilder\n: Turns files into pages.\n\nMenu\n: The links of a directory.\n")
+
+	expected := "<dl>\n<dt>Builder</dt>\n<dd>Turns files into pages.</dd>\n<dt>Menu</dt>\n<dd>The links of a directory.</dd>\n</dl>\n"
+
+	if out := string(b.markdown(src)); strings.Contains(out, expected) == false {
+		t.Fatalf("Expecting %q in %q", expected, out)
+	}
+
+	b.Markdown.DefinitionLists = false
+
+	expected = "<p>Builder\n: Turns files into pages.</p>\n"
+
+	if out := string(b.markdown(src)); strings.Contains(out, expected) == false || strings.Contains(out, "<dl>") {
+		t.Fatalf("Expecting plain paragraphs, %q, in %q", expected, out)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Returns when a file was last changed: the date of its last commit, if
+// GitModTime is set and the file is committed, or its modification time.
+func (b *Builder) modTime(file string) time.Time {
+	if b.GitModTime {
+		if date, ok := gitModTime(file); ok {
+			return date
+		}
+	}
+
+	stat, err := os.Stat(file)
+
+	if err != nil {
+		return time.Time{}
+	}
+
+	return stat.ModTime()
+}
+
+// Returns the date of the last commit that changed file, false if git is not
+// available or the file is not tracked.
+func gitModTime(file string) (time.Time, bool) {
+	out, err := exec.Command("git", "-C", filepath.Dir(file), "log", "-1", "--format=%cI", "--", filepath.Base(file)).Output()
+
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	date, err := time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return date, true
+}
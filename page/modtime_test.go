@@ -0,0 +1,60 @@
+package page
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestGitModTime(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+
+	b := testBuilder(t, map[string]string{
+		"committed.md": "# Committed",
+	})
+
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = b.Root
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.org",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.org",
+			"GIT_AUTHOR_DATE=2013-04-01T12:00:00Z", "GIT_COMMITTER_DATE=2013-04-01T12:00:00Z")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s %s", args, err, out)
+		}
+	}
+
+	git("init", "-q")
+	git("add", "committed.md")
+	git("commit", "-q", "-m", "Add committed.md")
+
+	if err := os.WriteFile(b.Root+PS+"untracked.md", []byte("# Untracked"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	b.GitModTime = true
+
+	p, err := b.Build(b.Root + PS + "committed.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.ModTime.Equal(time.Date(2013, 4, 1, 12, 0, 0, 0, time.UTC)) == false {
+		t.Fatalf("Expecting the commit date, got %v", p.ModTime)
+	}
+
+	p, err = b.Build(b.Root + PS + "untracked.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stat, _ := os.Stat(b.Root + PS + "untracked.md")
+
+	if p.ModTime.Equal(stat.ModTime()) == false {
+		t.Fatalf("Expecting the file's modification time, got %v", p.ModTime)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+// Returns the directory under Root a mounted root appears as, and the mounted
+// root itself, for a file under one of the Mounts. The longest mount path
+// wins.
+func (b *Builder) mountOf(file string) (string, string, bool) {
+	if b == nil || len(b.Mounts) == 0 {
+		return "", "", false
+	}
+
+	point, dir := "", ""
+
+	for prefix, root := range b.Mounts {
+		candidate := b.Root + PS + strings.Trim(prefix, "/")
+		if (file == candidate || strings.HasPrefix(file, candidate+PS)) && len(candidate) > len(point) {
+			point, dir = candidate, strings.TrimRight(root, PS)
+		}
+	}
+
+	return point, dir, point != ""
+}
+
+// Returns the path, on its mounted root, of a file under one of the Mounts,
+// or the file itself.
+func (b *Builder) MountedFile(file string) string {
+	if point, dir, ok := b.mountOf(file); ok {
+		return dir + file[len(point):]
+	}
+	return file
+}
+
+// Returns the path under the content root of a file on one of the mounted
+// roots, or the file itself.
+func (b *Builder) unmountedFile(file string) string {
+	if b == nil {
+		return file
+	}
+	for prefix, root := range b.Mounts {
+		root = strings.TrimRight(root, PS)
+		if file == root || strings.HasPrefix(file, root+PS) {
+			return b.Root + PS + strings.Trim(prefix, "/") + file[len(root):]
+		}
+	}
+	return file
+}
+
+// The entry of a mounted root on the directory it's mounted in, named after
+// its mount path.
+type mountEntry struct {
+	os.FileInfo
+	name string
+}
+
+func (m mountEntry) Name() string {
+	return m.name
+}
+
+func (m mountEntry) Type() os.FileMode {
+	return m.Mode().Type()
+}
+
+func (m mountEntry) Info() (os.FileInfo, error) {
+	return m, nil
+}
+
+// Adds the roots mounted right within directory to its entries, unless
+// there's an entry with the same name already.
+func (b *Builder) withMounts(directory string, ls []os.DirEntry) []os.DirEntry {
+	if b == nil || len(b.Mounts) == 0 {
+		return ls
+	}
+
+	seen := map[string]bool{}
+	for _, entry := range ls {
+		seen[entry.Name()] = true
+	}
+
+	for prefix, root := range b.Mounts {
+		prefix = strings.Trim(prefix, "/")
+		if path.Clean(b.Root+PS+path.Dir(prefix)) != path.Clean(directory) || seen[path.Base(prefix)] {
+			continue
+		}
+		stat, err := os.Stat(root)
+		if err != nil || stat.IsDir() == false {
+			Logger.Printf("Skipping mount %s: %s is not a directory.\n", prefix, root)
+			continue
+		}
+		ls = append(ls, mountEntry{stat, path.Base(prefix)})
+		seen[path.Base(prefix)] = true
+	}
+
+	return ls
+}
@@ -0,0 +1,119 @@
+package page
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMounts(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":       "# Home",
+		"guide/intro.md": "# Intro",
+	})
+
+	b.Mounts = map[string]string{
+		"plugins":     fixture(t, map[string]string{"index.md": "# Plugins", "cache.md": "# Cache"}),
+		"guide/extra": fixture(t, map[string]string{"tips.md": "# Tips"}),
+	}
+
+	tests := map[string]string{
+		"/plugins/cache":    "/plugins/cache.md",
+		"/guide/extra/tips": "/guide/extra/tips.md",
+		"/plugins/":         "/plugins/index.md",
+		"/guide/intro":      "/guide/intro.md",
+	}
+
+	for url, expected := range tests {
+		file, transform := b.Resolve(b.Root + url)
+		if transform != MARKDOWN_TRANSFORM || file != b.Root+expected {
+			t.Fatalf("Expecting %s to resolve to %s, got %s (%d)", url, expected, file, transform)
+		}
+	}
+
+	if file, transform := b.Resolve(b.Root + "/plugins"); transform != REDIRECT_TRANSFORM || file != b.Root+"/plugins/" {
+		t.Fatalf("Expecting a redirection to the mounted root's index, got %s (%d)", file, transform)
+	}
+
+	p, err := b.Build(b.Root + "/plugins/cache.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Link != "/plugins/cache" || p.Title != "Cache" {
+		t.Fatalf("Expecting the mounted page at /plugins/cache, got %s (%s)", p.Link, p.Title)
+	}
+
+	p, err = b.Build(b.Root + "/guide/extra/tips.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Link != "/guide/extra/tips" {
+		t.Fatalf("Expecting the mounted page at /guide/extra/tips, got %s", p.Link)
+	}
+
+	if url, err := b.URLByPath("plugins/cache.md"); err != nil || url != "/plugins/cache" {
+		t.Fatalf("Expecting /plugins/cache, got %q (%v)", url, err)
+	}
+
+	p, err = b.Build(b.Root + "/index.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, item := range p.Menu {
+		if item["link"] == "/plugins/" && item["text"] == "Plugins" {
+			found = true
+		}
+	}
+	if found == false {
+		t.Fatalf("Expecting the mounted root on the menu, got %v", p.Menu)
+	}
+}
+
+func TestMountsWalked(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":       "# Home",
+		"guide/intro.md": "---\ntags: [basics]\n---\n# Intro",
+	})
+
+	b.Mounts = map[string]string{
+		"plugins":     fixture(t, map[string]string{"index.md": "# Plugins", "cache.md": "---\ntags: [basics]\n---\n# Cache", "_draft.md": "# Draft"}),
+		"guide/extra": fixture(t, map[string]string{"tips.md": "# Tips"}),
+	}
+
+	urls, err := b.AllURLs(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"/", "/guide/extra/tips", "/guide/intro", "/plugins/", "/plugins/cache"}
+	if reflect.DeepEqual(urls, expected) == false {
+		t.Fatalf("Expecting mounted pages among %v, got %v", expected, urls)
+	}
+
+	if urls, err = b.AllURLs(b.Root + PS + "plugins"); err != nil || reflect.DeepEqual(urls, []string{"/plugins/", "/plugins/cache"}) == false {
+		t.Fatalf("Expecting the pages of a mounted root, got %v (%v)", urls, err)
+	}
+
+	sitemap, err := b.BuildSitemap(b.Root, "http://example.org/")
+	if err != nil || strings.Contains(string(sitemap), "<loc>http://example.org/plugins/cache</loc>") == false {
+		t.Fatalf("Expecting mounted pages on the sitemap, got %s (%v)", sitemap, err)
+	}
+
+	if counts, err := b.TagCounts(b.Root); err != nil || counts["basics"] != 2 {
+		t.Fatalf("Expecting mounted pages to be counted, got %v (%v)", counts, err)
+	}
+
+	out := t.TempDir()
+	if err := b.Export(out, template.Must(template.New("page").Parse("{{ .Title }}"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf, err := os.ReadFile(filepath.Join(out, "guide", "extra", "tips", "index.html")); err != nil || string(buf) != "Tips" {
+		t.Fatalf("Expecting mounted pages to be exported, got %q (%v)", buf, err)
+	}
+}
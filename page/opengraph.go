@@ -0,0 +1,92 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// Returns an absolute URL for a link relative to the content root.
+func (b *Builder) absoluteURL(siteURL string, link string) string {
+	if isExternalLinkPattern.MatchString(link) {
+		return link
+	}
+	siteURL = strings.TrimRight(siteURL, "/")
+	if b != nil && b.Prefix != "" {
+		siteURL = siteURL + "/" + b.Prefix
+	}
+	return siteURL + "/" + strings.TrimLeft(link, "/")
+}
+
+// Like absoluteURL, for a link of the page with the given front matter: its
+// "baseurl" key, if any, is used instead of siteURL (and the Prefix) for
+// pages canonically hosted elsewhere.
+func (b *Builder) pageURL(meta map[string]interface{}, siteURL string, link string) string {
+	if base := metaString(meta, "baseurl"); base != "" && isExternalLinkPattern.MatchString(link) == false {
+		return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(link, "/")
+	}
+	return b.absoluteURL(siteURL, link)
+}
+
+// Returns the canonical URL of the current page, siteURL is the scheme and
+// host the site is served at (i.e: "http://example.org").
+func (p *Page) Canonical(siteURL string) string {
+	return p.builder.pageURL(p.fullMeta(), siteURL, p.Link)
+}
+
+// Returns Open Graph <meta> tags for the current page, siteURL is the scheme
+// and host the site is served at (i.e: "http://example.org").
+func (p *Page) OpenGraph(siteURL string) template.HTML {
+	ogType := "website"
+	if p.builder != nil && p.builder.OpenGraphType != "" {
+		ogType = p.builder.OpenGraphType
+	}
+
+	tags := [][2]string{
+		{"og:title", p.Title},
+		{"og:description", p.Description},
+		{"og:url", p.Canonical(siteURL)},
+		{"og:type", ogType},
+	}
+
+	if image := metaString(p.fullMeta(), "image"); image != "" {
+		if strings.HasPrefix(image, "/") == false {
+			image = p.BasePath + image
+		}
+		tags = append(tags, [2]string{"og:image", p.builder.pageURL(p.fullMeta(), siteURL, image)})
+	}
+
+	out := []string{}
+
+	for _, tag := range tags {
+		if tag[1] == "" {
+			continue
+		}
+		out = append(out, fmt.Sprintf(`<meta property="%s" content="%s" />`, tag[0], template.HTMLEscapeString(tag[1])))
+	}
+
+	return template.HTML(strings.Join(out, "\n"))
+}
@@ -0,0 +1,94 @@
+package page
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenGraph(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/intro.md": "---\ndescription: Start here & enjoy.\nimage: diagram.png\n---\n# Introduction\n",
+		"guide/plain.md": "# Plain\n",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "guide", "intro.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	og := string(p.OpenGraph("http://example.org/"))
+
+	expected := []string{
+		`<meta property="og:title" content="Introduction" />`,
+		`<meta property="og:description" content="Start here &amp; enjoy." />`,
+		`<meta property="og:url" content="http://example.org/guide/intro" />`,
+		`<meta property="og:type" content="website" />`,
+		`<meta property="og:image" content="http://example.org/guide/diagram.png" />`,
+	}
+
+	for _, tag := range expected {
+		if strings.Contains(og, tag) == false {
+			t.Fatalf("Expecting %s in:\n%s", tag, og)
+		}
+	}
+
+	b.OpenGraphType = "article"
+
+	p, err = b.Build(filepath.Join(b.Root, "guide", "plain.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	og = string(p.OpenGraph("http://example.org"))
+
+	if strings.Contains(og, "og:image") {
+		t.Fatalf("Expecting no og:image without an image, got:\n%s", og)
+	}
+
+	if strings.Contains(og, `<meta property="og:type" content="article" />`) == false {
+		t.Fatalf("Expecting the configured og:type, got:\n%s", og)
+	}
+}
+
+func TestBaseURLOverride(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/moved.md": "---\nbaseurl: https://other.example.com/docs/\nimage: /shot.png\n---\n# Moved\n",
+		"guide/plain.md": "# Plain\n",
+	})
+
+	b.Prefix = "site"
+
+	p, err := b.Build(filepath.Join(b.Root, "guide", "moved.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if canonical := p.Canonical("http://example.org"); canonical != "https://other.example.com/docs/guide/moved" {
+		t.Fatalf("Expecting the canonical URL on the page's base URL, got %s", canonical)
+	}
+
+	og := string(p.OpenGraph("http://example.org"))
+
+	if strings.Contains(og, `content="https://other.example.com/docs/guide/moved"`) == false || strings.Contains(og, `content="https://other.example.com/docs/shot.png"`) == false {
+		t.Fatalf("Expecting absolute links on the page's base URL, got:\n%s", og)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "guide", "plain.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if canonical := p.Canonical("http://example.org"); canonical != "http://example.org/site/guide/plain" {
+		t.Fatalf("Expecting the canonical URL on the site's URL, got %s", canonical)
+	}
+
+	sitemap, err := b.BuildSitemap(b.Root, "http://example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(sitemap), "<loc>http://example.org/site/guide/plain</loc>") == false || strings.Contains(string(sitemap), "other.example.com") {
+		t.Fatalf("Expecting only the pages hosted on the site's URL in:\n%s", sitemap)
+	}
+}
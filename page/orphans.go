@@ -0,0 +1,144 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Returns the URLs of the published pages under root (a directory within the
+// content root) that can't be reached by following menus from root, like
+// pages within directories whose names begin with "_" or HTML pages (side
+// menus only list Markdown and reStructuredText files, and HTML files marked
+// keep_extension).
+// Sorted.
+func (b *Builder) FindOrphans(root string) ([]string, error) {
+	reachable := map[string]bool{}
+
+	var visit func(dir string) error
+
+	visit = func(dir string) error {
+		// Directories on the overlay and on the mounted roots too.
+		_, found := b.findIndex(b.source(dir))
+		if found == false {
+			_, found = b.findIndex(b.MountedFile(dir))
+		}
+		if found {
+			url, err := b.URLByPath(b.relPath(dir))
+			if err != nil {
+				return err
+			}
+			reachable[url] = true
+		}
+
+		for _, file := range b.filterList(dir, b.pageFilter) {
+			if b.isMenuFile(b.MountedFile(dir), file.Name(), b.metaOf(b.source(filepath.Join(dir, file.Name())))) == false {
+				continue
+			}
+			url, err := b.URLByPath(b.relPath(filepath.Join(dir, file.Name())))
+			if err != nil {
+				return err
+			}
+			reachable[url] = true
+		}
+
+		for _, sub := range b.filterList(dir, directoryFilter) {
+			if err := visit(filepath.Join(dir, sub.Name())); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := visit(root); err != nil {
+		return nil, err
+	}
+
+	orphans := []string{}
+
+	// Directories beginning with "_" are still served, files such as
+	// _header.md are only included.
+	err := b.walkServedPages(root, func(file string, info os.FileInfo) error {
+		meta, err := b.readMeta(b.source(file))
+
+		if err != nil {
+			return err
+		}
+
+		if b.isPublished(meta) == false {
+			return nil
+		}
+
+		url, err := b.URLByPath(b.relPath(file))
+
+		if err != nil {
+			return err
+		}
+
+		if reachable[url] == false {
+			orphans = append(orphans, url)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(orphans)
+
+	return orphans, nil
+}
+
+// Tells whether a published page under the content root could not be reached
+// by following menus, like FindOrphans does for a whole tree.
+func (b *Builder) isOrphan(file string) bool {
+	dir := path.Dir(file)
+
+	for _, chunk := range strings.Split(b.relPath(dir), "/") {
+		if chunk != "." && (strings.HasPrefix(chunk, ".") || strings.HasPrefix(chunk, "_")) {
+			return true
+		}
+	}
+
+	for _, candidate := range []string{dir, removeKnownExtension(file)} {
+		if index, found := b.findIndex(b.MountedFile(candidate)); found && index == b.MountedFile(file) {
+			return false
+		}
+	}
+
+	name := path.Base(file)
+
+	if isHidden(name) {
+		return false
+	}
+
+	return b.isMenuFile(b.MountedFile(dir), name, b.metaOf(b.source(file))) == false
+}
@@ -0,0 +1,69 @@
+package page
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindOrphans(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":             "# Home",
+		"_header.md":           "Header",
+		"about.md":             "# About",
+		"guide/index.md":       "# Guide",
+		"guide/intro.md":       "# Intro",
+		"guide/setup.rst":      "Setup\n=====\n",
+		"guide/demo.html":      "---\nkeep_extension: true\n---\n<h1>Demo</h1>",
+		"guide/deep/more/x.md": "# X",
+		"guide/table.html":     "<h1>Table</h1>",
+		"_hidden/secret.md":    "# Secret",
+		"_hidden/draft.md":     "---\ndraft: true\n---\n# Draft",
+		".git/README.md":       "# Git",
+	})
+
+	orphans, err := b.FindOrphans(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"/_hidden/secret", "/guide/table"}
+
+	if reflect.DeepEqual(orphans, expected) == false {
+		t.Fatalf("Expecting orphans %v, got %v", expected, orphans)
+	}
+
+	// Pages on the mounted roots and on the overlay are checked too.
+	b.Mounts = map[string]string{
+		"plugins": fixture(t, map[string]string{"index.md": "# Plugins", "cache.md": "# Cache", "raw.html": "<h1>Raw</h1>", "_old/gone.md": "# Gone"}),
+	}
+	b.Overlay = fixture(t, map[string]string{"extra/index.md": "# Extras", "extra/tips.md": "# Tips", "extra/page.html": "<h1>Page</h1>"})
+
+	orphans, err = b.FindOrphans(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []string{"/_hidden/secret", "/extra/page", "/guide/table", "/plugins/_old/gone", "/plugins/raw"}
+
+	if reflect.DeepEqual(orphans, expected) == false {
+		t.Fatalf("Expecting orphans %v, got %v", expected, orphans)
+	}
+}
+
+func TestStrictModeReStructuredText(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":         "# Home",
+		"guide/index.md":   "# Guide",
+		"guide/setup.rst":  "Setup\n=====\n\nSome text.\n",
+		"guide/table.html": "<h1>Table</h1>",
+	})
+	b.StrictMode = true
+
+	if _, err := b.Build(b.Root + PS + "guide/setup.rst"); err != nil {
+		t.Fatalf("Expecting .rst pages listed on side menus not to be orphans, got %v", err)
+	}
+
+	if _, err := b.Build(b.Root + PS + "guide/table.html"); err == nil {
+		t.Fatalf("Expecting HTML pages to remain orphans.")
+	}
+}
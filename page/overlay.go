@@ -0,0 +1,97 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"os"
+	"strings"
+)
+
+// Returns the path, on the overlay, of a file under the content root.
+func (b *Builder) overlayOf(file string) (string, bool) {
+	if b == nil || b.Overlay == "" || strings.HasPrefix(file, b.Overlay+PS) || file == b.Overlay {
+		return "", false
+	}
+	if file != b.Root && strings.HasPrefix(file, b.Root+PS) == false {
+		return "", false
+	}
+	return b.Overlay + file[len(b.Root):], true
+}
+
+// Returns the file to read for a file under the content root: its copy on
+// the overlay, if any, or the file itself (on its mounted root, if it's under
+// one of the Mounts).
+func (b *Builder) source(file string) string {
+	if overlay, ok := b.overlayOf(file); ok {
+		if _, err := os.Stat(overlay); err == nil {
+			return overlay
+		}
+	}
+	return b.MountedFile(file)
+}
+
+// Returns the entries of a directory under the content root merged with
+// those of the same directory on the overlay, entries on the overlay win.
+// Roots mounted within the directory are listed as directories.
+func (b *Builder) readEntries(directory string) []os.DirEntry {
+	return b.withMounts(directory, b.readMergedEntries(directory))
+}
+
+func (b *Builder) readMergedEntries(directory string) []os.DirEntry {
+	overlay, ok := b.overlayOf(directory)
+
+	directory = b.MountedFile(directory)
+
+	if ok == false {
+		return readEntries(directory)
+	}
+
+	if _, err := os.Stat(overlay); err != nil {
+		return readEntries(directory)
+	}
+
+	ls := readEntries(overlay)
+
+	if _, err := os.Stat(directory); err != nil {
+		return ls
+	}
+
+	seen := map[string]bool{}
+	for _, entry := range ls {
+		seen[entry.Name()] = true
+	}
+
+	for _, entry := range readEntries(directory) {
+		if seen[entry.Name()] == false {
+			ls = append(ls, entry)
+		}
+	}
+
+	return ls
+}
+
+// Like filterList, with the overlay's entries merged in.
+func (b *Builder) filterList(directory string, filter func(os.FileInfo) bool) fileList {
+	return filterEntries(directory, b.readEntries(directory), filter)
+}
@@ -0,0 +1,58 @@
+package page
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOverlay(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md": "# Guide",
+		"guide/intro.md": "# Intro",
+		"guide/setup.md": "# Setup",
+	})
+
+	b.Overlay = fixture(t, map[string]string{
+		"guide/intro.md":   "# Intro (staging)",
+		"guide/preview.md": "# Preview",
+	})
+
+	file, transform := b.Resolve(b.Root + "/guide/intro")
+	if transform != MARKDOWN_TRANSFORM || file != b.Root+"/guide/intro.md" {
+		t.Fatalf("Expecting guide/intro.md, got %s (%d)", file, transform)
+	}
+
+	p, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Intro (staging)" {
+		t.Fatalf("Expecting the overlay to shadow the base file, got %q", p.Title)
+	}
+
+	if _, transform := b.Resolve(b.Root + "/guide/preview"); transform != MARKDOWN_TRANSFORM {
+		t.Fatalf("Expecting overlay only files to resolve, got %d", transform)
+	}
+
+	file, transform = b.Resolve(b.Root + "/guide/setup")
+	if transform != MARKDOWN_TRANSFORM {
+		t.Fatalf("Expecting base only files to resolve, got %d", transform)
+	}
+
+	p, err = b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Setup" {
+		t.Fatalf("Expecting the base file, got %q", p.Title)
+	}
+
+	links := []string{}
+	for _, item := range p.SideMenu {
+		links = append(links, item["link"].(string))
+	}
+
+	if strings.Join(links, " ") != "/guide/intro /guide/preview /guide/setup" {
+		t.Fatalf("Expecting the overlay's pages on the side menu, got %v", links)
+	}
+}
@@ -0,0 +1,241 @@
+package page
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPrevNext(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":           "# Home",
+		"a/index.md":         "# A",
+		"a/first.md":         "# First",
+		"a/last.md":          "---\nweight: 10\n---\n# Last",
+		"b/index.md":         "# B",
+		"b/one.md":           "# One",
+		"b/nested/deeper.md": "# Deeper",
+	})
+
+	link := func(item map[string]interface{}) interface{} {
+		if item == nil {
+			return nil
+		}
+		return item["link"]
+	}
+
+	p, err := b.Build(b.Root + PS + "a/last.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link(p.Prev) != "/a/first" || p.Next != nil {
+		t.Fatalf("Expecting the directory's order, got %v and %v", link(p.Prev), link(p.Next))
+	}
+
+	b.PrevNextAcrossSections = true
+
+	tests := []struct {
+		file, prev, next interface{}
+	}{
+		{"index.md", nil, "/a/"},
+		{"a/index.md", "/", "/a/first"},
+		{"a/last.md", "/a/first", "/b/"},
+		{"b/index.md", "/a/last", "/b/one"},
+		{"b/one.md", "/b/", "/b/nested/deeper"},
+		{"b/nested/deeper.md", "/b/one", nil},
+	}
+
+	for _, test := range tests {
+		p, err := b.Build(b.Root + PS + test.file.(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link(p.Prev) != test.prev || link(p.Next) != test.next {
+			t.Fatalf("Expecting %s between %v and %v, got %v and %v", test.file, test.prev, test.next, link(p.Prev), link(p.Next))
+		}
+	}
+
+	p, _ = b.Build(b.Root + PS + "a/last.md")
+	if p.Next["text"] != "B" {
+		t.Fatalf("Expecting the next section to be named after its index, got %v", p.Next["text"])
+	}
+}
+
+func TestPrevNextCached(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":           "# Home",
+		"a/index.md":         "# A",
+		"b/nested/deeper.md": "# Deeper",
+	})
+
+	b.PrevNextAcrossSections = true
+
+	next := func(file string) interface{} {
+		p, err := b.Build(b.Root + PS + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Next == nil {
+			return nil
+		}
+		return p.Next["link"]
+	}
+
+	if link := next("a/index.md"); link != "/b/nested/deeper" {
+		t.Fatalf("Expecting /b/nested/deeper next, got %v", link)
+	}
+
+	added := b.Root + PS + "b" + PS + "nested" + PS + "closer.md"
+	if err := os.WriteFile(added, []byte("# Closer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if link := next("a/index.md"); link != "/b/nested/deeper" {
+		t.Fatalf("Expecting the reading order to be cached, got %v", link)
+	}
+
+	b.InvalidateFiles([]string{added})
+
+	if link := next("a/index.md"); link != "/b/nested/closer" {
+		t.Fatalf("Expecting the reading order to follow changes, got %v", link)
+	}
+}
+
+func TestPrevNextScheduled(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":   "# Home",
+		"a/index.md": "# A",
+		"b/index.md": "---\ndate: 2013-06-01\n---\n# B",
+		"c/index.md": "---\nexpires: 2013-07-01\n---\n# C",
+	})
+
+	now := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+	b.Now = func() time.Time { return now }
+	b.PrevNextAcrossSections = true
+
+	next := func(file string) interface{} {
+		p, err := b.Build(b.Root + PS + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Next == nil {
+			return nil
+		}
+		return p.Next["link"]
+	}
+
+	if link := next("a/index.md"); link != "/c/" {
+		t.Fatalf("Expecting the scheduled section to be skipped, got %v", link)
+	}
+
+	now = time.Date(2013, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	if link := next("a/index.md"); link != "/b/" {
+		t.Fatalf("Expecting the section once published, got %v", link)
+	}
+
+	now = time.Date(2013, 7, 2, 0, 0, 0, 0, time.UTC)
+
+	if link := next("b/index.md"); link != nil {
+		t.Fatalf("Expecting the expired section to be left out, got %v", link)
+	}
+}
+
+func TestSiblingPosition(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"a/index.md": "# A",
+		"a/alpha.md": "# Alpha",
+		"a/beta.md":  "# Beta",
+		"a/gamma.md": "# Gamma",
+		"b/lone.md":  "# Lone",
+	})
+
+	tests := []struct {
+		file         string
+		index, count int
+	}{
+		{"a/alpha.md", 0, 3},
+		{"a/beta.md", 1, 3},
+		{"a/gamma.md", 2, 3},
+		{"a/index.md", -1, 3},
+		{"b/lone.md", 0, 1},
+	}
+
+	for _, test := range tests {
+		p, err := b.Build(b.Root + PS + test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.SiblingIndex != test.index || p.SiblingCount != test.count {
+			t.Fatalf("Expecting %s to be %d of %d, got %d of %d", test.file, test.index, test.count, p.SiblingIndex, p.SiblingCount)
+		}
+	}
+}
+
+func TestSiblingPositionScheduled(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"a/index.md": "# A",
+		"a/alpha.md": "# Alpha",
+		"a/beta.md":  "---\ndate: 2013-06-01\n---\n# Beta",
+		"a/gamma.md": "---\nexpires: 2013-07-01\n---\n# Gamma",
+	})
+
+	now := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+	b.Now = func() time.Time { return now }
+
+	position := func(file string) (int, int) {
+		p, err := b.Build(b.Root + PS + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.SiblingIndex, p.SiblingCount
+	}
+
+	if index, count := position("a/gamma.md"); index != 1 || count != 2 {
+		t.Fatalf("Expecting gamma to be 1 of 2, got %d of %d", index, count)
+	}
+
+	now = time.Date(2013, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	if index, count := position("a/gamma.md"); index != 2 || count != 3 {
+		t.Fatalf("Expecting gamma to be 2 of 3 once beta is published, got %d of %d", index, count)
+	}
+
+	now = time.Date(2013, 7, 2, 0, 0, 0, 0, time.UTC)
+
+	if index, count := position("a/beta.md"); index != 1 || count != 2 {
+		t.Fatalf("Expecting beta to be 1 of 2 once gamma expires, got %d of %d", index, count)
+	}
+}
+
+func TestSiblingsListedOnce(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"a/index.md": "# A",
+		"a/alpha.md": "# Alpha",
+		"a/beta.md":  "# Beta",
+	})
+
+	if _, err := b.Build(b.Root + PS + "a/alpha.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	added := b.Root + PS + "a" + PS + "gamma.md"
+	if err := os.WriteFile(added, []byte("# Gamma"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Its own side menu lists the directory again, its siblings don't.
+	p, err := b.Build(b.Root + PS + "a/beta.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.SideMenu) != 3 || p.SiblingCount != 2 || p.Next != nil {
+		t.Fatalf("Expecting the siblings listed for alpha.md to be reused, got %d of %d", p.SiblingIndex, p.SiblingCount)
+	}
+
+	b.InvalidateFiles([]string{added})
+
+	if p, err = b.Build(b.Root + PS + "a/beta.md"); err != nil || p.SiblingCount != 3 || p.Next["link"] != "/a/gamma" {
+		t.Fatalf("Expecting siblings to follow changes, got %d of %d (%v)", p.SiblingIndex, p.SiblingCount, err)
+	}
+}
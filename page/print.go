@@ -0,0 +1,110 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var headingTagPattern = regexp.MustCompile(`<(/?)h([1-6])\b`)
+
+// Moves every heading of the given HTML down by levels (i.e: <h1> becomes
+// <h2> if levels is 1), headings can't go below <h6>.
+func shiftHeadings(content string, levels int) string {
+	return headingTagPattern.ReplaceAllStringFunc(content, func(tag string) string {
+		m := headingTagPattern.FindStringSubmatch(tag)
+		level, _ := strconv.Atoi(m[2])
+		if level += levels; level > 6 {
+			level = 6
+		}
+		return fmt.Sprintf("<%sh%d", m[1], level)
+	})
+}
+
+// Returns a single page with the content of every page of dir (relative to
+// the content root), for printing: its index first, then its pages in side
+// menu order. Headings are moved one level down, so the page's title is the
+// only top level heading.
+func (b *Builder) BuildPrintView(dir string) (*Page, error) {
+	rel := strings.Trim(path.Clean("/"+dir), "/")
+
+	directory := strings.TrimRight(b.Root+PS+rel, PS)
+
+	if b.isListable(directory) == false {
+		return nil, fmt.Errorf("Could not print %s: not a directory.", dir)
+	}
+
+	p := b.NewPage(directory + PS + "index")
+
+	p.Title = b.fileTitle(rel + "/index")
+
+	_, files := p.sideMenuItems()
+
+	index, hasIndex := b.findIndex(directory)
+
+	if hasIndex {
+		files = append([]string{index}, files...)
+	}
+
+	parts := []string{}
+
+	for i, file := range files {
+		if err := b.checkSize(b.source(file)); err != nil {
+			return nil, err
+		}
+
+		meta, src, err := b.readSource(b.source(file))
+
+		if err != nil {
+			return nil, err
+		}
+
+		content, _ := b.NewPage(file).renderContent(src)
+
+		if i == 0 && hasIndex {
+			// The index names the whole section.
+			if title := metaString(meta, "title"); title != "" {
+				p.Title = title
+			} else if title := b.extractTitle(string(content)); title != "" {
+				p.Title = title
+			}
+		}
+
+		parts = append(parts, shiftHeadings(string(content), 1))
+	}
+
+	p.Content = template.HTML(strings.Join(parts, "\n"))
+
+	p.CreateBreadCrumb()
+	p.CreateParent()
+	p.CreateMenu()
+	p.CreateSideMenu()
+
+	return p, nil
+}
@@ -0,0 +1,59 @@
+package page
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildPrintView(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/_section.yaml": "sort: weight\n",
+		"guide/index.md":      "# The guide\n\nIntro.\n",
+		"guide/a-last.md":     "---\nweight: 3\n---\n# Last\n\n## Details\n",
+		"guide/b-first.md":    "---\nweight: 1\n---\n# First\n\n###### Deepest\n",
+		"guide/c-second.md":   "---\nweight: 2\n---\n# Second\n",
+	})
+
+	p, err := b.BuildPrintView("guide")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Title != "The guide" {
+		t.Fatalf("Expecting the index's title, got %q", p.Title)
+	}
+
+	content := string(p.Content)
+
+	links := []string{}
+	for _, item := range p.SideMenu {
+		links = append(links, item["link"].(string))
+	}
+
+	if strings.Join(links, " ") != "/guide/b-first /guide/c-second /guide/a-last" {
+		t.Fatalf("Unexpected side menu %v", links)
+	}
+
+	last := -1
+	for _, heading := range []string{"<h2>The guide</h2>", "<h2>First</h2>", "<h2>Second</h2>", "<h2>Last</h2>"} {
+		i := strings.Index(content, heading)
+		if i <= last {
+			t.Fatalf("Expecting the index, then the pages in side menu order, got %q", content)
+		}
+		last = i
+	}
+
+	if strings.Contains(content, "<h1") {
+		t.Fatalf("Expecting no top level headings, got %s", content)
+	}
+
+	for _, heading := range []string{"<h3>Details</h3>", "<h6>Deepest</h6>"} {
+		if strings.Contains(content, heading) == false {
+			t.Fatalf("Expecting %s, got %s", heading, content)
+		}
+	}
+
+	if _, err := b.BuildPrintView("missing"); err == nil {
+		t.Fatalf("Expecting an error for a missing directory")
+	}
+}
@@ -0,0 +1,122 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Name of the file, on the content root, that lists redirects.
+const redirectsFile = "_redirects"
+
+// A rule of the _redirects file: requests for From are redirected to To with
+// the given status. A From ending with "/*" matches every path below it, the
+// matched part replaces ":splat" in To.
+type Redirect struct {
+	From   string
+	To     string
+	Status int
+}
+
+// Returns where the rule redirects urlPath to, if it matches.
+func (r Redirect) Target(urlPath string) (string, bool) {
+	if strings.HasSuffix(r.From, "/*") {
+		base := strings.TrimSuffix(r.From, "*")
+		if strings.HasPrefix(urlPath, base) || urlPath+"/" == base {
+			splat := strings.TrimPrefix(strings.TrimPrefix(urlPath, strings.TrimSuffix(base, "/")), "/")
+			return strings.Replace(r.To, ":splat", splat, -1), true
+		}
+		return "", false
+	}
+	if urlPath == r.From {
+		return r.To, true
+	}
+	return "", false
+}
+
+// Reads the _redirects file of root, one "from to [status]" rule per line
+// (the status defaults to 301). Blank lines and lines beginning with "#" are
+// skipped. Returns no rules if there is no such file.
+func ParseRedirects(root string) ([]Redirect, error) {
+	file := filepath.Join(root, redirectsFile)
+
+	fp, err := os.Open(file)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Redirect{}, nil
+		}
+		return nil, fmt.Errorf("Error trying to open %s: %s", file, err.Error())
+	}
+
+	defer fp.Close()
+
+	rules := []Redirect{}
+
+	scanner := bufio.NewScanner(fp)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("%s:%d: expecting \"from to [status]\", got %q.", file, line, text)
+		}
+
+		rule := Redirect{From: fields[0], To: fields[1], Status: 301}
+
+		if strings.HasPrefix(rule.From, "/") == false {
+			return nil, fmt.Errorf("%s:%d: %q is not a rooted path.", file, line, rule.From)
+		}
+
+		if strings.Contains(strings.TrimSuffix(rule.From, "/*"), "*") {
+			return nil, fmt.Errorf("%s:%d: only a trailing \"/*\" is allowed in %q.", file, line, rule.From)
+		}
+
+		if len(fields) == 3 {
+			rule.Status, err = strconv.Atoi(fields[2])
+			if err != nil || rule.Status < 300 || rule.Status > 399 {
+				return nil, fmt.Errorf("%s:%d: %q is not a redirect status.", file, line, fields[2])
+			}
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error trying to read %s: %s", file, err.Error())
+	}
+
+	return rules, nil
+}
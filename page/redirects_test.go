@@ -0,0 +1,72 @@
+package page
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRedirects(t *testing.T) {
+	root := fixture(t, map[string]string{
+		"_redirects": "# Moved pages\n" +
+			"/old-guide /guide/\n\n" +
+			"/blog/*   /news/:splat   302\n",
+	})
+
+	rules, err := ParseRedirects(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Redirect{
+		{"/old-guide", "/guide/", 301},
+		{"/blog/*", "/news/:splat", 302},
+	}
+
+	if reflect.DeepEqual(rules, expected) == false {
+		t.Fatalf("Expecting %v, got %v", expected, rules)
+	}
+
+	targets := map[string]string{
+		"/old-guide":      "/guide/",
+		"/blog/2013/hi":   "/news/2013/hi",
+		"/blog":           "/news/",
+		"/old-guide/more": "",
+		"/blogger":        "",
+	}
+
+	for urlPath, want := range targets {
+		got := ""
+		for _, rule := range rules {
+			if target, ok := rule.Target(urlPath); ok {
+				got = target
+				break
+			}
+		}
+		if got != want {
+			t.Fatalf("%s: expecting %q, got %q", urlPath, want, got)
+		}
+	}
+}
+
+func TestParseRedirectsMalformed(t *testing.T) {
+	for _, content := range []string{
+		"/a /b\n/only-one\n",
+		"/a /b abc\n",
+		"/a /b 200\n",
+		"/a/*/b /c\n",
+	} {
+		root := fixture(t, map[string]string{"_redirects": content})
+
+		_, err := ParseRedirects(root)
+
+		if err == nil || strings.Contains(err.Error(), "_redirects:") == false {
+			t.Fatalf("%q: expecting an error with the line, got %v", content, err)
+		}
+	}
+
+	rules, err := ParseRedirects(fixture(t, map[string]string{"index.md": "# Home"}))
+	if err != nil || len(rules) != 0 {
+		t.Fatalf("Expecting no rules without a _redirects file, got %v, %v", rules, err)
+	}
+}
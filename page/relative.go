@@ -0,0 +1,72 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	// A src or href attribute.
+	linkAttrPattern = regexp.MustCompile(`(?i)(\s(?:src|href)\s*=\s*)("[^"]*"|'[^']*')`)
+	// A URL with a scheme (i.e: "http:", "mailto:", "data:").
+	schemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// Rewrites the relative src and href attributes of the rendered HTML so they
+// point to the same files whatever URL the page is served at (i.e: "img/a.png"
+// on /guide/intro.md becomes "/guide/img/a.png"). Rooted, external and
+// fragment-only links are left as they are.
+func (p *Page) absoluteLinks(content string) string {
+	return linkAttrPattern.ReplaceAllStringFunc(content, func(attr string) string {
+		m := linkAttrPattern.FindStringSubmatch(attr)
+
+		quote := m[2][:1]
+		link := m[2][1 : len(m[2])-1]
+
+		if link == "" || strings.HasPrefix(link, "/") || strings.HasPrefix(link, "#") || schemePattern.MatchString(link) {
+			return attr
+		}
+
+		rest := ""
+		if i := strings.IndexAny(link, "?#"); i >= 0 {
+			link, rest = link[:i], link[i:]
+		}
+
+		base := p.BasePath
+		if p.linkBase != "" {
+			base = p.linkBase
+		}
+
+		abs := path.Join(strings.TrimRight(p.builder.mountPath(), "/")+base, link)
+
+		if strings.HasSuffix(link, "/") && abs != "/" {
+			abs = abs + "/"
+		}
+
+		return m[1] + quote + abs + rest + quote
+	})
+}
@@ -0,0 +1,46 @@
+package page
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAbsoluteLinks(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/intro.md": "# Intro\n\n" +
+			"![Diagram](img/diagram.png)\n\n" +
+			"[Up](../about.md#team) [Ext](http://example.org/a.png) [Root](/rooted/b.png) [Top](#intro) [Mail](mailto:a@example.org)\n",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "guide", "intro.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := string(p.Content)
+
+	for _, expected := range []string{
+		`src="/guide/img/diagram.png"`,
+		`href="/about.md#team"`,
+		`href="http://example.org/a.png"`,
+		`href="/rooted/b.png"`,
+		`href="#intro"`,
+		`href="mailto:a@example.org"`,
+	} {
+		if strings.Contains(content, expected) == false {
+			t.Fatalf("Expecting %s, got %q", expected, content)
+		}
+	}
+
+	b.Prefix = "docs"
+
+	p, err = b.Build(filepath.Join(b.Root, "guide", "intro.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), `src="/docs/guide/img/diagram.png"`) == false {
+		t.Fatalf("Expecting links under the mount path, got %q", p.Content)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Tags whose content replaceText leaves as it is.
+var verbatimTags = map[string]bool{
+	"code":   true,
+	"pre":    true,
+	"script": true,
+	"style":  true,
+}
+
+// How the content of pages is rendered, by extension (see Builder.Renderers).
+const (
+	// Parsed as markdown.
+	RENDERER_MARKDOWN = "markdown"
+	// Used as it is, as HTML.
+	RENDERER_HTML = "html"
+	// Escaped and wrapped in a <pre>, as preformatted text.
+	RENDERER_PRE = "pre"
+	// Converted from reStructuredText by the builder's RST renderer.
+	RENDERER_RST = "rst"
+)
+
+// What to do with requests that only match a file of an extension without a
+// renderer (see Builder.UnknownExtensions).
+const (
+	UNKNOWN_EXTENSIONS_NOT_FOUND = "not_found"
+	UNKNOWN_EXTENSIONS_RAW       = "raw"
+)
+
+// Returns the renderer of the given file, by its extension, or "" if it has
+// none.
+func (b *Builder) rendererOf(file string) string {
+	switch ext := path.Ext(file); ext {
+	case ".md":
+		return RENDERER_MARKDOWN
+	case ".html":
+		return RENDERER_HTML
+	case ".rst":
+		return RENDERER_RST
+	default:
+		if b == nil {
+			return ""
+		}
+		return b.Renderers[ext]
+	}
+}
+
+// Returns the extensions tried, in order, when a page is requested without
+// one: pageExtensions, then the others of the Renderers in alphabetical
+// order.
+func (b *Builder) pageExtensions() []string {
+	if b == nil || len(b.Renderers) == 0 {
+		return pageExtensions
+	}
+
+	extra := []string{}
+
+	for ext := range b.Renderers {
+		if removeKnownExtension(ext) == ext {
+			extra = append(extra, ext)
+		}
+	}
+
+	sort.Strings(extra)
+
+	return append(append([]string{}, pageExtensions...), extra...)
+}
+
+// Returns the given file name without its extension, if it's one of the known
+// extensions or one of the Renderers'.
+func (b *Builder) pageName(name string) string {
+	if b != nil {
+		if _, ok := b.Renderers[path.Ext(name)]; ok {
+			return name[:len(name)-len(path.Ext(name))]
+		}
+	}
+	return removeKnownExtension(name)
+}
+
+// Returns the source as preformatted text.
+func renderPre(src []byte) []byte {
+	return []byte("<pre>" + template.HTMLEscapeString(string(src)) + "</pre>\n")
+}
+
+// Returns the first file, in alphabetical order, named like the given one
+// plus an extension without a renderer (i.e: "data.csv" for "data"), to be
+// served as it is when UnknownExtensions is UNKNOWN_EXTENSIONS_RAW. Files
+// whose front matter makes them unpublished are not served.
+func (b *Builder) rawFile(file string) (string, bool) {
+	if b.UnknownExtensions != UNKNOWN_EXTENSIONS_RAW || isHidden(path.Base(file)) {
+		return "", false
+	}
+
+	matches, _ := filepath.Glob(file + ".*")
+
+	for _, match := range matches {
+		if match[:len(match)-len(path.Ext(match))] != file || b.rendererOf(match) != "" {
+			continue
+		}
+		if stat, err := os.Stat(match); err == nil && stat.IsDir() == false && b.isPublishedFile(match) {
+			return match, true
+		}
+	}
+
+	return "", false
+}
+
+// Returns the file to serve, as it is, for a file Resolve returned with
+// RAW_TRANSFORM.
+func (b *Builder) RawFile(file string) string {
+	return b.source(file)
+}
+
+// Returns the given HTML with f applied to its text only: tags (attributes
+// included) and whatever is within <code>, <pre>, <script> or <style> are
+// left as they are.
+func replaceText(content string, f func(string) string) string {
+	var out bytes.Buffer
+
+	depth, last := 0, 0
+
+	text := func(s string) {
+		if depth == 0 {
+			s = f(s)
+		}
+		out.WriteString(s)
+	}
+
+	for _, loc := range tagPattern.FindAllStringIndex(content, -1) {
+		text(content[last:loc[0]])
+		tag := content[loc[0]:loc[1]]
+		out.WriteString(tag)
+		if found := inlineTagPattern.FindStringSubmatch(tag); found != nil && verbatimTags[strings.ToLower(found[2])] {
+			if found[1] == "" {
+				depth++
+			} else if depth > 0 {
+				depth--
+			}
+		}
+		last = loc[1]
+	}
+
+	text(content[last:])
+
+	return out.String()
+}
@@ -0,0 +1,101 @@
+package page
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderers(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"notes.txt": "Use <b> & \"quotes\"\n",
+		"data.csv":  "a,b\n1,2\n",
+	})
+
+	if _, transform := b.Resolve(b.Root + "/notes"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting .txt pages not to be found without a renderer, got %d", transform)
+	}
+
+	b.Renderers = map[string]string{".txt": RENDERER_PRE}
+
+	file, transform := b.Resolve(b.Root + "/notes")
+	if transform != MARKDOWN_TRANSFORM || file != b.Root+"/notes.txt" {
+		t.Fatalf("Expecting notes.txt to be served as a page, got %s (%d)", file, transform)
+	}
+
+	p, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "<pre>Use &lt;b&gt; &amp; &#34;quotes&#34;\n</pre>\n"; string(p.Content) != expected {
+		t.Fatalf("Expecting %q, got %q", expected, p.Content)
+	}
+
+	// Unmapped extensions are not found by default.
+	if _, transform := b.Resolve(b.Root + "/data"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting data.csv not to be found, got %d", transform)
+	}
+
+	b.UnknownExtensions = UNKNOWN_EXTENSIONS_RAW
+
+	file, transform = b.Resolve(b.Root + "/data")
+	if transform != RAW_TRANSFORM || b.RawFile(file) != b.Root+"/data.csv" {
+		t.Fatalf("Expecting data.csv to be served as it is, got %s (%d)", file, transform)
+	}
+}
+
+func TestRendererPagesListed(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":  "# Home\n",
+		"notes.txt": "Some notes\n",
+		"data.csv":  "a,b\n1,2\n",
+	})
+
+	b.Renderers = map[string]string{".txt": RENDERER_PRE}
+
+	if kind := b.ContentType(b.Root+"/notes.txt", false); kind != "text/html; charset=utf-8" {
+		t.Fatalf("Expecting notes.txt to be served as HTML, got %s", kind)
+	}
+
+	if kind := b.ContentType(b.Root+"/notes.txt", true); kind != "text/plain; charset=utf-8" {
+		t.Fatalf("Expecting the source of notes.txt to be plain text, got %s", kind)
+	}
+
+	if kind := ContentType(b.Root+"/notes.txt", false); kind != "text/plain; charset=utf-8" {
+		t.Fatalf("Expecting notes.txt to be plain text without a renderer, got %s", kind)
+	}
+
+	p, err := b.Build(b.Root + "/index.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, item := range p.SideMenu {
+		if item["link"] == "/notes" && item["text"] == "Notes" {
+			found = true
+		}
+	}
+	if found == false {
+		t.Fatalf("Expecting notes.txt on the side menu, got %v", p.SideMenu)
+	}
+
+	urls, err := b.AllURLs(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"/", "/notes"}; reflect.DeepEqual(urls, expected) == false {
+		t.Fatalf("Expecting %v, got %v", expected, urls)
+	}
+
+	sitemap, err := b.BuildSitemap(b.Root, "http://example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(sitemap), "<loc>http://example.org/notes</loc>") == false {
+		t.Fatalf("Expecting notes in the sitemap, got %s", sitemap)
+	}
+}
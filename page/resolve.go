@@ -0,0 +1,270 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"mime"
+	"os"
+	"path"
+	"strings"
+)
+
+// Checks for files that could be transformed into the requested file
+
+// Extensions tried, in order, when a page is requested without one.
+var pageExtensions = []string{".md", ".html", ".rst"}
+
+const (
+	NO_TRANSFORM       = iota
+	MARKDOWN_TRANSFORM = iota
+	REDIRECT_TRANSFORM = iota
+	LISTING_TRANSFORM  = iota
+	MOVED_TRANSFORM    = iota
+	RAW_TRANSFORM      = iota
+	// The directory's default page (see defaultChild), a temporary redirect
+	// unlike MOVED_TRANSFORM, as the default may change.
+	DEFAULT_TRANSFORM = iota
+)
+
+// Returns the first index file, in order of precedence, that exists in the
+// given directory.
+//
+// When a directory and a page share a name (i.e: guide/ and guide.md) the
+// directory wins: both are served at /guide/ and the page becomes the
+// directory's index, unless the directory has an index file of its own.
+func (b *Builder) findIndex(dir string) (string, bool) {
+	dir = strings.TrimRight(dir, "/")
+	for _, name := range b.IndexPrecedence {
+		actualpath := dir + "/" + name
+		stat, err := os.Stat(actualpath)
+		if err == nil && stat.IsDir() == false {
+			return actualpath, true
+		}
+	}
+	if stat, err := os.Stat(dir); err != nil || stat.IsDir() == false || dir == b.Root || dir == b.Overlay {
+		return "", false
+	}
+	for _, ext := range b.pageExtensions() {
+		actualpath := dir + ext
+		stat, err := os.Stat(actualpath)
+		if err == nil && stat.IsDir() == false {
+			return actualpath, true
+		}
+	}
+	return "", false
+}
+
+// Returns the file to serve for the requested file, and the transformation it
+// needs. Pages on the overlay, if any, win over those under the content root,
+// their paths (and those of pages on Mounts) are returned as if they were
+// under the content root.
+func (b *Builder) Resolve(file string) (string, int) {
+	if overlay, ok := b.overlayOf(file); ok {
+		if actualpath, transform := b.resolve(overlay); transform != NO_TRANSFORM {
+			return b.Root + actualpath[len(b.Overlay):], transform
+		}
+	}
+	if point, dir, ok := b.mountOf(file); ok {
+		actualpath, transform := b.resolve(dir + file[len(point):])
+		if strings.HasPrefix(actualpath, dir) {
+			actualpath = point + actualpath[len(dir):]
+		}
+		return actualpath, transform
+	}
+	return b.resolve(file)
+}
+
+// Returns the HomeDocument, if any, when dir is the content root (or the
+// overlay's root).
+func (b *Builder) homeDocument(dir string) (string, bool) {
+	dir = strings.TrimRight(dir, "/")
+	if b.HomeDocument == "" || (dir != b.Root && (b.Overlay == "" || dir != b.Overlay)) {
+		return "", false
+	}
+	home := dir + PS + strings.TrimLeft(b.HomeDocument, "/")
+	if stat, err := os.Stat(home); err != nil || stat.IsDir() || b.isPublishedFile(home) == false {
+		return "", false
+	}
+	return home, true
+}
+
+// Tells whether file is the HomeDocument.
+func (b *Builder) isHomeDocument(file string) bool {
+	if b == nil || b.HomeDocument == "" {
+		return false
+	}
+	return path.Clean(file) == path.Clean(b.Root+PS+b.HomeDocument)
+}
+
+// Returns the page named by the "default" key of the directory's
+// _section.yaml (i.e: "overview" for guide/overview.md), if it's served.
+func (b *Builder) defaultChild(dir string) (string, bool) {
+	dir = strings.TrimRight(dir, "/")
+
+	name := strings.Trim(metaString(loadSection(dir), "default"), "/")
+
+	if name == "" {
+		return "", false
+	}
+
+	if strings.HasPrefix(path.Clean(dir+"/"+name), dir+"/") == false {
+		Logger.Printf("The default page of %s, %s, is not within it.\n", dir, name)
+		return "", false
+	}
+
+	child, transform := b.resolve(dir + "/" + name)
+
+	if transform != MARKDOWN_TRANSFORM {
+		Logger.Printf("The default page of %s, %s, is not served.\n", dir, name)
+		return "", false
+	}
+
+	return child, true
+}
+
+func (b *Builder) resolve(file string) (string, int) {
+	if strings.HasSuffix(file, "/") {
+		Logger.Printf("Trailing slash... [%s]\n", file)
+		// They specified the trailing '/'
+		if home, found := b.homeDocument(file); found {
+			return home, MARKDOWN_TRANSFORM
+		}
+		actualpath, found := b.findIndex(file)
+		if found && b.isPublishedFile(actualpath) {
+			Logger.Printf(" it's a hit... [%s]\n", actualpath)
+			return actualpath, MARKDOWN_TRANSFORM
+		}
+		if child, ok := b.defaultChild(file); found == false && ok {
+			return child, DEFAULT_TRANSFORM
+		}
+		if found == false && b.AutoIndex && b.isListable(file) {
+			return file, LISTING_TRANSFORM
+		}
+		if found == false && b.LinkStyle == LINK_STYLE_SLASH {
+			// Page links end with a slash too.
+			return b.Resolve(strings.TrimRight(file, "/"))
+		}
+		return file, NO_TRANSFORM
+	}
+	// no trailing "/" in the request
+	stat, err := os.Stat(file)
+	if err == nil {
+		Logger.Printf("No trailing slash... [%s]\n", file)
+		if stat.IsDir() {
+			// check to see if there is an index there,
+			// if so we want to redirect them to the proper "/"
+			// (note that the main reason we don't
+			// want to denote directories without the trailing
+			// slash is the interpretation of relative references
+			// within the directory's index.md, like an image ref)
+			actualpath, found := b.findIndex(file)
+			if found && b.isPublishedFile(actualpath) {
+				if b.LinkStyle == LINK_STYLE_PLAIN {
+					// Directory links have no trailing slash.
+					return actualpath, MARKDOWN_TRANSFORM
+				}
+				return file + "/", REDIRECT_TRANSFORM
+			}
+			// well, the name exists and it is a directory,
+			// but there is no index in it... unless it has a
+			// default page or auto indexes are enabled,
+			// tough luck
+			if child, ok := b.defaultChild(file); found == false && ok {
+				return child, DEFAULT_TRANSFORM
+			}
+			if found == false && b.AutoIndex && b.isListable(file) {
+				return file + "/", REDIRECT_TRANSFORM
+			}
+			return file, NO_TRANSFORM
+		}
+	}
+
+	for _, ext := range b.pageExtensions() {
+		actualpath := file + ext
+		_, err = os.Stat(actualpath)
+		if err == nil {
+			if b.isPublishedFile(actualpath) == false {
+				break
+			}
+			meta, _ := b.readMeta(actualpath)
+			served, err := b.servedName(path.Dir(actualpath), path.Base(actualpath), meta)
+			if err != nil {
+				break
+			}
+			if served != path.Base(file) {
+				// Served at its slug instead.
+				if b.SlugRedirects {
+					return actualpath, MOVED_TRANSFORM
+				}
+				break
+			}
+			return actualpath, MARKDOWN_TRANSFORM
+		}
+	}
+	if actualpath, found := b.findSlug(path.Dir(file), path.Base(file)); found {
+		return actualpath, MARKDOWN_TRANSFORM
+	}
+	if actualpath, found := b.rawFile(file); found {
+		return actualpath, RAW_TRANSFORM
+	}
+	return file + pageExtensions[0], NO_TRANSFORM
+}
+
+// Content types of extensions the mime package may not know about.
+var contentTypes = map[string]string{
+	".md": "text/markdown; charset=utf-8",
+}
+
+// Returns the content type a file is served with, pages are HTML once they're
+// rendered, their source if raw is true. Unknown types are served as
+// application/octet-stream.
+func ContentType(file string, raw bool) string {
+	return (*Builder)(nil).ContentType(file, raw)
+}
+
+// Like ContentType, pages of the Renderers are HTML too.
+func (b *Builder) ContentType(file string, raw bool) string {
+	ext := strings.ToLower(path.Ext(file))
+
+	if raw == false {
+		for _, pageExt := range pageExtensions {
+			if ext == pageExt {
+				return "text/html; charset=utf-8"
+			}
+		}
+		if b.rendererOf(file) != "" {
+			return "text/html; charset=utf-8"
+		}
+	}
+
+	if kind, ok := contentTypes[ext]; ok {
+		return kind
+	}
+
+	if kind := mime.TypeByExtension(ext); kind != "" {
+		return kind
+	}
+
+	return "application/octet-stream"
+}
@@ -0,0 +1,452 @@
+package page
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveIndexPrecedence(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"both/index.md":   "# Markdown",
+		"both/index.html": "<h1>HTML</h1>",
+		"only/index.html": "<h1>HTML</h1>",
+	})
+
+	file, transform := b.Resolve(b.Root + "/both/")
+	if transform != MARKDOWN_TRANSFORM || filepath.Base(file) != "index.md" {
+		t.Fatalf("Expecting index.md to win by default, got %s (%d)", file, transform)
+	}
+
+	b.IndexPrecedence = []string{"index.html", "index.md"}
+
+	file, transform = b.Resolve(b.Root + "/both/")
+	if transform != MARKDOWN_TRANSFORM || filepath.Base(file) != "index.html" {
+		t.Fatalf("Expecting index.html to win, got %s (%d)", file, transform)
+	}
+
+	b.IndexPrecedence = []string{"index.md", "index.html"}
+
+	file, transform = b.Resolve(b.Root + "/only/")
+	if transform != MARKDOWN_TRANSFORM || filepath.Base(file) != "index.html" {
+		t.Fatalf("Expecting the only index to be used, got %s (%d)", file, transform)
+	}
+
+	if _, transform = b.Resolve(b.Root + "/only"); transform != REDIRECT_TRANSFORM {
+		t.Fatalf("Expecting a redirect for a directory without trailing slash.")
+	}
+}
+
+func TestResolvePageExtensions(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"intro.md":   "# Intro",
+		"table.html": "<h1>Table</h1>",
+	})
+
+	for name, expected := range map[string]string{"intro": "intro.md", "table": "table.html"} {
+		file, transform := b.Resolve(b.Root + "/" + name)
+		if transform != MARKDOWN_TRANSFORM || filepath.Base(file) != expected {
+			t.Fatalf("%s: expecting %s, got %s (%d)", name, expected, file, transform)
+		}
+	}
+
+	if _, transform := b.Resolve(b.Root + "/missing"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting nothing to serve for a missing page.")
+	}
+}
+
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		file     string
+		raw      bool
+		expected string
+	}{
+		{"/guide/intro.md", false, "text/html; charset=utf-8"},
+		{"/guide/table.html", false, "text/html; charset=utf-8"},
+		{"/guide/intro.md", true, "text/markdown; charset=utf-8"},
+		{"/images/logo.png", false, "image/png"},
+		{"/images/logo.PNG", true, "image/png"},
+		{"/files/unknown.zzz", true, "application/octet-stream"},
+	}
+
+	for _, test := range tests {
+		if kind := ContentType(test.file, test.raw); kind != test.expected {
+			t.Fatalf("%s (raw: %v): expecting %q, got %q", test.file, test.raw, test.expected, kind)
+		}
+	}
+}
+
+func TestResolveSameNameDirectory(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":        "# Home",
+		"guide.md":        "# Guide",
+		"guide/intro.md":  "# Intro",
+		"about.md":        "# About",
+		"about/index.md":  "# About us",
+		"about/people.md": "# People",
+	})
+
+	if file, transform := b.Resolve(b.Root + "/guide"); transform != REDIRECT_TRANSFORM {
+		t.Fatalf("Expecting a redirect to the directory, got %s (%d)", file, transform)
+	}
+
+	if file, _ := b.Resolve(b.Root + "/guide/"); file != filepath.Join(b.Root, "guide.md") {
+		t.Fatalf("Expecting guide.md to be the directory's index, got %s", file)
+	}
+
+	if file, _ := b.Resolve(b.Root + "/about/"); file != filepath.Join(b.Root, "about", "index.md") {
+		t.Fatalf("Expecting the directory's own index to win, got %s", file)
+	}
+
+	if url, _ := b.URLByPath("guide.md"); url != "/guide/" {
+		t.Fatalf("Expecting guide.md at /guide/, got %s", url)
+	}
+
+	p := b.NewPage(filepath.Join(b.Root, "index.md"))
+	p.CreateMenu()
+	p.CreateSideMenu()
+
+	links := []string{}
+	for _, item := range append(p.Menu, p.SideMenu...) {
+		links = append(links, item["link"].(string))
+	}
+
+	if reflect.DeepEqual(links, []string{"/about/", "/guide/"}) == false {
+		t.Fatalf("Expecting a single entry per name, got %v", links)
+	}
+}
+
+func TestBuildSameNameDirectory(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":       "# Home",
+		"guide.md":       "# Guide\n\n![Diagram](diagram.png)\n",
+		"guide/intro.md": "# Intro",
+		"other.md":       "# Other",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "guide.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Link != "/guide/" || p.BasePath != "/guide/" || p.FileDir != filepath.Join(b.Root, "guide")+PS {
+		t.Fatalf("Expecting the page to be built as guide/'s index, got %q, %q and %q", p.Link, p.BasePath, p.FileDir)
+	}
+
+	if strings.Contains(string(p.Content), `src="/guide/diagram.png"`) == false {
+		t.Fatalf("Expecting relative links against /guide/, got %s", p.Content)
+	}
+
+	if len(p.SideMenu) != 1 || p.SideMenu[0]["link"] != "/guide/intro" {
+		t.Fatalf("Expecting guide/'s side menu, got %v", p.SideMenu)
+	}
+
+	texts := []interface{}{}
+	for _, crumb := range p.BreadCrumb {
+		texts = append(texts, crumb["text"])
+	}
+
+	if reflect.DeepEqual(texts, []interface{}{"Home", "Guide"}) == false || p.ActiveSection != "guide" {
+		t.Fatalf("Expecting guide/'s breadcrumb, got %v (%q)", texts, p.ActiveSection)
+	}
+
+	if p, _ := b.Build(filepath.Join(b.Root, "other.md")); p.Link != "/other" || p.BasePath != "/" {
+		t.Fatalf("Expecting other pages to be left alone, got %q and %q", p.Link, p.BasePath)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md":              "# Guide",
+		"guide/installation-guide.md": "---\nslug: install\n---\n# Installation",
+		"guide/setup.md":              "# Setup",
+	})
+
+	file, transform := b.Resolve(b.Root + "/guide/install")
+	if transform != MARKDOWN_TRANSFORM || file != b.Root+"/guide/installation-guide.md" {
+		t.Fatalf("Expecting the page at its slug, got %s (%d)", file, transform)
+	}
+
+	p, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Link != "/guide/install" {
+		t.Fatalf("Expecting the page's link to be its slug, got %s", p.Link)
+	}
+
+	links := []interface{}{}
+	for _, item := range p.SideMenu {
+		links = append(links, item["link"])
+	}
+	if reflect.DeepEqual(links, []interface{}{"/guide/install", "/guide/setup"}) == false {
+		t.Fatalf("Expecting the menu link to use the slug, got %v", links)
+	}
+
+	if _, transform := b.Resolve(b.Root + "/guide/installation-guide"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting the file name URL not to be found, got %d", transform)
+	}
+
+	b.SlugRedirects = true
+
+	if _, transform := b.Resolve(b.Root + "/guide/installation-guide"); transform != MOVED_TRANSFORM {
+		t.Fatalf("Expecting the file name URL to redirect, got %d", transform)
+	}
+
+	if url, err := b.URLByPath("guide/installation-guide.md"); err != nil || url != "/guide/install" {
+		t.Fatalf("Expecting /guide/install, got %q (%v)", url, err)
+	}
+}
+
+func TestSlugCollisions(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md":   "# Guide",
+		"guide/a-setup.md": "---\nslug: install\n---\n# A",
+		"guide/b-setup.md": "---\nslug: install\n---\n# B",
+	})
+
+	if url, err := b.URLByPath("guide/a-setup.md"); err != nil || url != "/guide/install" {
+		t.Fatalf("Expecting the first page to get the slug, got %q (%v)", url, err)
+	}
+	if url, err := b.URLByPath("guide/b-setup.md"); err != nil || url != "/guide/b-setup" {
+		t.Fatalf("Expecting the second page to keep its name, got %q (%v)", url, err)
+	}
+	if file, transform := b.Resolve(b.Root + "/guide/b-setup"); transform != MARKDOWN_TRANSFORM || file != b.Root+"/guide/b-setup.md" {
+		t.Fatalf("Expecting the second page at its name, got %s (%d)", file, transform)
+	}
+
+	b.SlugCollisions = SLUG_COLLISION_SUFFIX
+
+	if file, transform := b.Resolve(b.Root + "/guide/install-2"); transform != MARKDOWN_TRANSFORM || file != b.Root+"/guide/b-setup.md" {
+		t.Fatalf("Expecting the second page at install-2, got %s (%d)", file, transform)
+	}
+
+	b.SlugCollisions = SLUG_COLLISION_ERROR
+
+	if _, err := b.URLByPath("guide/b-setup.md"); err == nil {
+		t.Fatalf("Expecting the colliding page to fail.")
+	}
+
+	problems, err := b.ValidateFrontMatter(b.Root, nil)
+	if err != nil || len(problems) != 1 || problems[0].File != "guide/b-setup.md" || problems[0].Key != "slug" {
+		t.Fatalf("Expecting the collision to be reported, got %v (%v)", problems, err)
+	}
+
+	p, err := b.Build(b.Root + "/guide/a-setup.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links := []interface{}{}
+	for _, item := range p.SideMenu {
+		links = append(links, item["link"])
+	}
+	if reflect.DeepEqual(links, []interface{}{"/guide/install"}) == false {
+		t.Fatalf("Expecting the colliding page out of the menu, got %v", links)
+	}
+}
+
+func TestSlugTableCached(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md": "# Guide",
+		"guide/setup.md": "---\nslug: install\n---\n# Setup",
+	})
+
+	if url, err := b.URLByPath("guide/setup.md"); err != nil || url != "/guide/install" {
+		t.Fatalf("Expecting /guide/install, got %q (%v)", url, err)
+	}
+
+	changed := b.Root + PS + "guide" + PS + "setup.md"
+	if err := os.WriteFile(changed, []byte("---\nslug: installing\n---\n# Setup"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if file, _ := b.Resolve(b.Root + "/guide/install"); file != changed {
+		t.Fatalf("Expecting the slug table to be kept until it's invalidated, got %s", file)
+	}
+
+	b.InvalidateFiles([]string{changed})
+
+	if url, err := b.URLByPath("guide/setup.md"); err != nil || url != "/guide/installing" {
+		t.Fatalf("Expecting /guide/installing, got %q (%v)", url, err)
+	}
+}
+
+func TestSlugTableScheduled(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"news/index.md":    "# News",
+		"news/announce.md": "---\nslug: launch\ndate: 2013-06-01\n---\n# Launch",
+		"news/sale.md":     "---\nslug: deals\nexpires: 2013-07-01\n---\n# Sale",
+	})
+
+	now := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+	b.Now = func() time.Time { return now }
+
+	if _, transform := b.Resolve(b.Root + "/news/launch"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting the scheduled slug not to be served yet, got %d", transform)
+	}
+
+	if _, transform := b.Resolve(b.Root + "/news/deals"); transform != MARKDOWN_TRANSFORM {
+		t.Fatalf("Expecting the current slug to be served, got %d", transform)
+	}
+
+	now = time.Date(2013, 6, 2, 0, 0, 0, 0, time.UTC)
+
+	if file, transform := b.Resolve(b.Root + "/news/launch"); transform != MARKDOWN_TRANSFORM || file != b.Root+"/news/announce.md" {
+		t.Fatalf("Expecting the slug once its page is published, got %s (%d)", file, transform)
+	}
+
+	now = time.Date(2013, 7, 2, 0, 0, 0, 0, time.UTC)
+
+	if _, transform := b.Resolve(b.Root + "/news/deals"); transform != NO_TRANSFORM {
+		t.Fatalf("Expecting the slug of the expired page not to be served, got %d", transform)
+	}
+}
+
+func TestFindSlugNotFound(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md": "# Guide",
+		"guide/setup.md": "---\nslug: install\n---\n# Setup",
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, transform := b.Resolve(b.Root + "/guide/missing"); transform == MARKDOWN_TRANSFORM {
+			t.Fatalf("Expecting /guide/missing not to be found.")
+		}
+		b.Resolve(b.Root + fmt.Sprintf("/nowhere-%d/missing", i))
+	}
+
+	if len(b.slugCache) != 1 {
+		t.Fatalf("Expecting a single slug table, for guide/, got %d", len(b.slugCache))
+	}
+
+	if file, transform := b.Resolve(b.Root + "/guide/install"); transform != MARKDOWN_TRANSFORM || file != b.Root+"/guide/setup.md" {
+		t.Fatalf("Expecting the cached table to resolve slugs, got %s (%d)", file, transform)
+	}
+}
+
+func TestHomeDocument(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":   "# Index",
+		"welcome.md": "# Welcome",
+		"about.md":   "# About",
+	})
+
+	file, transform := b.Resolve(b.Root + "/")
+	if transform != MARKDOWN_TRANSFORM || file != b.Root+"/index.md" {
+		t.Fatalf("Expecting the root index by default, got %s (%d)", file, transform)
+	}
+
+	b.HomeDocument = "welcome.md"
+
+	file, transform = b.Resolve(b.Root + "/")
+	if transform != MARKDOWN_TRANSFORM || file != b.Root+"/welcome.md" {
+		t.Fatalf("Expecting the home document, got %s (%d)", file, transform)
+	}
+
+	p, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.IsHome == false || p.Link != "/" || p.Title != "Welcome" {
+		t.Fatalf("Expecting the home page, got %v %q %q", p.IsHome, p.Link, p.Title)
+	}
+
+	for _, item := range p.SideMenu {
+		if item["link"] == "/welcome" {
+			t.Fatalf("Expecting the home document to be left out of the side menu, got %v", p.SideMenu)
+		}
+	}
+}
+
+func TestNestedHomeDocument(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"about.md":        "# About",
+		"docs/index.md":   "# Docs",
+		"docs/intro.md":   "# Intro",
+		"docs/welcome.md": "# Welcome\n\nStart with the [intro](intro).\n",
+	})
+
+	b.HomeDocument = "docs/welcome.md"
+
+	file, transform := b.Resolve(b.Root + "/")
+	if transform != MARKDOWN_TRANSFORM || file != b.Root+"/docs/welcome.md" {
+		t.Fatalf("Expecting the home document, got %s (%d)", file, transform)
+	}
+
+	p, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.IsHome == false || p.Link != "/" || p.BasePath != "/" {
+		t.Fatalf("Expecting the home page, got %v %q %q", p.IsHome, p.Link, p.BasePath)
+	}
+
+	if strings.Contains(string(p.Content), `href="/docs/intro"`) == false {
+		t.Fatalf("Expecting relative links to stay relative to docs/, got %s", p.Content)
+	}
+
+	links := []interface{}{}
+	for _, item := range p.SideMenu {
+		links = append(links, item["link"])
+	}
+	if reflect.DeepEqual(links, []interface{}{"/about"}) == false {
+		t.Fatalf("Expecting the root's side menu, got %v", links)
+	}
+
+	if len(p.Menu) != 1 || p.Menu[0]["link"] != "/docs/" {
+		t.Fatalf("Expecting the root's menu, got %v", p.Menu)
+	}
+
+	p, err = b.Build(b.Root + "/about.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.IsHome {
+		t.Fatalf("Expecting only the home document to be the home page.")
+	}
+}
+
+func TestDefaultChild(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/_section.yaml": "default: overview\n",
+		"guide/overview.md":   "# Overview",
+		"guide/setup.md":      "# Setup",
+		"docs/_section.yaml":  "title: Docs\n",
+		"docs/index.md":       "# Docs",
+		"notes/a.md":          "# A",
+		"away/_section.yaml":  "default: ../docs/index\n",
+		"away/b.md":           "# B",
+	})
+
+	b.AutoIndex = true
+
+	for _, url := range []string{"/guide/", "/guide"} {
+		file, transform := b.Resolve(b.Root + url)
+		if transform != DEFAULT_TRANSFORM || file != b.Root+"/guide/overview.md" {
+			t.Fatalf("Expecting %s to redirect to its default page, got %s (%d)", url, file, transform)
+		}
+		if target, err := b.URLByPath(file[len(b.Root):]); err != nil || target != "/guide/overview" {
+			t.Fatalf("Expecting /guide/overview, got %q (%v)", target, err)
+		}
+	}
+
+	if file, transform := b.Resolve(b.Root + "/docs/"); transform != MARKDOWN_TRANSFORM || file != b.Root+"/docs/index.md" {
+		t.Fatalf("Expecting the index without a default page, got %s (%d)", file, transform)
+	}
+
+	if _, transform := b.Resolve(b.Root + "/notes/"); transform != LISTING_TRANSFORM {
+		t.Fatalf("Expecting a listing without a default page, got %d", transform)
+	}
+
+	if file, transform := b.Resolve(b.Root + "/away/"); transform != LISTING_TRANSFORM {
+		t.Fatalf("Expecting default pages outside of the directory to be ignored, got %s (%d)", file, transform)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bytes"
+	"html/template"
+	"strconv"
+	"strings"
+)
+
+// Converts reStructuredText (.rst) source to HTML. The package only ships
+// BasicRST, the default, which is a stub: set Builder.RST to plug in a
+// complete converter.
+type RSTRenderer interface {
+	RenderRST(src []byte) ([]byte, error)
+}
+
+// A stub RSTRenderer that only understands section titles (underlined, the
+// first style found is h1, the second h2 and so on) and paragraphs,
+// everything else is left as text.
+type BasicRST struct{}
+
+func (BasicRST) RenderRST(src []byte) ([]byte, error) {
+	var out bytes.Buffer
+
+	styles := []byte{}
+	paragraph := []string{}
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + template.HTMLEscapeString(strings.Join(paragraph, "\n")) + "</p>\n")
+			paragraph = []string{}
+		}
+	}
+
+	lines := strings.Split(strings.Replace(string(src), "\r\n", "\n", -1), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+
+		if line == "" {
+			flush()
+			continue
+		}
+
+		if i+1 < len(lines) && len(paragraph) == 0 && isRSTUnderline(lines[i+1], line) {
+			style := lines[i+1][0]
+			level := bytes.IndexByte(styles, style)
+			if level < 0 {
+				styles = append(styles, style)
+				level = len(styles) - 1
+			}
+			if level > 5 {
+				level = 5
+			}
+			tag := strconv.Itoa(level + 1)
+			out.WriteString("<h" + tag + ">" + template.HTMLEscapeString(line) + "</h" + tag + ">\n")
+			i++
+			continue
+		}
+
+		paragraph = append(paragraph, line)
+	}
+
+	flush()
+
+	return out.Bytes(), nil
+}
+
+// Tells whether underline is a line of punctuation at least as long as the
+// given title.
+func isRSTUnderline(underline string, title string) bool {
+	underline = strings.TrimRight(underline, " \t")
+
+	if len(underline) == 0 || len(underline) < len(title) || strings.ContainsRune(`=-~^"'*+#:.`+"`", rune(underline[0])) == false {
+		return false
+	}
+
+	return strings.Count(underline, underline[:1]) == len(underline)
+}
+
+// Renders reStructuredText source as HTML with the builder's RST renderer,
+// BasicRST if it has none. Sources that can't be converted are shown as
+// preformatted text.
+func (b *Builder) rst(src []byte) []byte {
+	var renderer RSTRenderer = BasicRST{}
+
+	if b != nil && b.RST != nil {
+		renderer = b.RST
+	}
+
+	out, err := renderer.RenderRST(src)
+
+	if err != nil {
+		Logger.Printf("Could not render reStructuredText: %s\n", err.Error())
+		return renderPre(src)
+	}
+
+	return out
+}
@@ -0,0 +1,58 @@
+package page
+
+import (
+	"strings"
+	"testing"
+)
+
+type stubRST struct{}
+
+func (stubRST) RenderRST(src []byte) ([]byte, error) {
+	return []byte("<h1>From RST</h1>\n<p>" + strings.TrimSpace(string(src)) + "</p>\n"), nil
+}
+
+func TestRST(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md":   "# Guide",
+		"guide/intro.md":   "# Intro",
+		"guide/legacy.rst": "Legacy\n======\n\nOld docs.\n",
+	})
+
+	b.RST = stubRST{}
+
+	file, transform := b.Resolve(b.Root + "/guide/legacy")
+	if transform != MARKDOWN_TRANSFORM || file != b.Root+"/guide/legacy.rst" {
+		t.Fatalf("Expecting legacy.rst to be found, got %s (%d)", file, transform)
+	}
+
+	p, err := b.Build(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), "<p>Legacy\n======\n\nOld docs.</p>") == false || p.Title != "From RST" {
+		t.Fatalf("Expecting the content and title to come from the RST renderer, got %q (%q)", p.Content, p.Title)
+	}
+
+	links := []interface{}{}
+	for _, item := range p.SideMenu {
+		links = append(links, item["link"])
+	}
+
+	if len(links) != 2 || links[1] != "/guide/legacy" {
+		t.Fatalf("Expecting the .rst page in the side menu, got %v", links)
+	}
+}
+
+func TestBasicRST(t *testing.T) {
+	out, err := BasicRST{}.RenderRST([]byte("Title\n=====\n\nSome <text>\nhere.\n\nPart\n----\n\nMore.\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "<h1>Title</h1>\n<p>Some &lt;text&gt;\nhere.</p>\n<h2>Part</h2>\n<p>More.</p>\n"
+
+	if string(out) != expected {
+		t.Fatalf("Expecting %q, got %q", expected, out)
+	}
+}
@@ -0,0 +1,194 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Name of the file that describes a directory (a "section").
+const sectionFile = "_section.yaml"
+
+// Reads the _section.yaml file of the given directory, returns nil if there is
+// none.
+func loadSection(dir string) map[string]interface{} {
+	file := strings.TrimRight(dir, PS) + PS + sectionFile
+
+	buf, err := ioutil.ReadFile(file)
+
+	if err != nil {
+		if os.IsNotExist(err) == false {
+			Logger.Printf("Could not read %s: %s\n", file, err.Error())
+		}
+		return nil
+	}
+
+	section := map[string]interface{}{}
+
+	err = yaml.Unmarshal(buf, &section)
+
+	if err != nil {
+		Logger.Printf("Could not parse %s: %s\n", file, err.Error())
+		return nil
+	}
+
+	return section
+}
+
+// Returns the front matter of the directory's index page, nil if it has none.
+func (b *Builder) indexMeta(dir string) map[string]interface{} {
+	var index string
+	var found bool
+
+	if b != nil {
+		index, found = b.findIndex(dir)
+	} else {
+		index, found = (&Builder{IndexPrecedence: []string{"index.md", "index.html"}}).findIndex(dir)
+	}
+
+	if found == false {
+		return nil
+	}
+
+	meta, err := b.readMeta(index)
+
+	if err != nil {
+		return nil
+	}
+
+	return meta
+}
+
+// Names the directory's menu item after the title of its index page, if any
+// (with its icon), then copies the title, icon, description and weight of the
+// directory's section, if any, into it.
+func (b *Builder) applySection(item map[string]interface{}, dir string) {
+	dir = b.MountedFile(dir)
+
+	if meta := b.indexMeta(dir); meta != nil {
+		if title := metaString(meta, "title"); title != "" {
+			item["text"] = title
+		}
+		applyMenuTitle(item, meta)
+		applyIcon(item, meta)
+	}
+
+	section := loadSection(dir)
+
+	if section == nil {
+		return
+	}
+
+	if title := metaString(section, "title"); title != "" {
+		item["text"] = title
+	}
+
+	applyMenuTitle(item, section)
+
+	for _, key := range []string{"icon", "description"} {
+		if value := metaString(section, key); value != "" {
+			item[key] = value
+		}
+	}
+
+	if _, ok := section["weight"]; ok {
+		item["weight"] = metaInt(section, "weight")
+	}
+}
+
+// Sets the "icon" of a menu item to that of the given front matter, if any.
+func applyIcon(item map[string]interface{}, meta map[string]interface{}) {
+	if icon := metaString(meta, "icon"); icon != "" {
+		item["icon"] = icon
+	}
+}
+
+// Orders the menu or listing items of a directory the way its section says:
+// "sort" is one of "name" (the default), "title", "date" or "weight" and
+// "order" is either "asc" (the default) or "desc". Items are expected in name
+// order. Undated items go last when sorting by date.
+func sortItems(items []map[string]interface{}, section map[string]interface{}) {
+	desc := strings.ToLower(metaString(section, "order")) == "desc"
+
+	var less func(a, b map[string]interface{}) bool
+
+	switch strings.ToLower(metaString(section, "sort")) {
+	case "title":
+		less = func(a, b map[string]interface{}) bool {
+			return strings.ToLower(metaString(a, "text")) < strings.ToLower(metaString(b, "text"))
+		}
+	case "weight":
+		less = func(a, b map[string]interface{}) bool {
+			return metaInt(a, "weight") < metaInt(b, "weight")
+		}
+	case "date":
+		less = func(a, b map[string]interface{}) bool {
+			da, aok := a["date"].(time.Time)
+			db, bok := b["date"].(time.Time)
+			if aok == false || bok == false {
+				// Undated items go last, either way (a and b are swapped
+				// when the order is descending).
+				if desc {
+					return bok && aok == false
+				}
+				return aok && bok == false
+			}
+			return da.Before(db)
+		}
+	default:
+		if desc {
+			for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+				items[i], items[j] = items[j], items[i]
+			}
+		}
+		return
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if desc {
+			return less(items[j], items[i])
+		}
+		return less(items[i], items[j])
+	})
+}
+
+// Returns the metadata of the top level section the page is in.
+func (p *Page) topSection() map[string]interface{} {
+	if p.builder == nil {
+		return nil
+	}
+
+	chunks := strings.Split(strings.Trim(p.BasePath, "/"), "/")
+
+	if chunks[0] == "" {
+		return nil
+	}
+
+	return loadSection(p.builder.Root + PS + chunks[0])
+}
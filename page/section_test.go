@@ -0,0 +1,182 @@
+package page
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSectionMetadata(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":                 "# Home",
+		"api-docs/_section.yaml":   "title: API Reference\nicon: book\ndescription: Every endpoint.\n",
+		"api-docs/index.md":        "# API",
+		"api-docs/v1/call.md":      "# Call",
+		"getting-started/index.md": "# Getting started",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.Menu) != 2 {
+		t.Fatalf("Expecting two menu entries, got %v", p.Menu)
+	}
+
+	api := p.Menu[0]
+	if api["text"] != "API Reference" || api["icon"] != "book" || api["description"] != "Every endpoint." {
+		t.Fatalf("Expecting the section metadata on the menu entry, got %v", api)
+	}
+
+	plain := p.Menu[1]
+	if plain["text"] != "Getting started" {
+		t.Fatalf("Expecting a title from the directory name, got %v", plain["text"])
+	}
+	if _, ok := plain["icon"]; ok {
+		t.Fatalf("Expecting no icon without a _section.yaml, got %v", plain)
+	}
+
+	if p.Section != nil {
+		t.Fatalf("Expecting no section on the home page, got %v", p.Section)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "api-docs", "v1", "call.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Section["icon"] != "book" {
+		t.Fatalf("Expecting the section of a nested page, got %v", p.Section)
+	}
+}
+
+func TestMenuIcons(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":          "# Home",
+		"guide/index.md":    "---\nicon: compass\n---\n# Guide",
+		"guide/install.md":  "---\nicon: download\n---\n# Install",
+		"guide/usage.md":    "# Usage",
+		"api/_section.yaml": "icon: book\n",
+		"api/index.md":      "---\nicon: ignored\n---\n# API",
+		"plain/index.md":    "# Plain",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	icons := func(items []map[string]interface{}) []interface{} {
+		out := []interface{}{}
+		for _, item := range items {
+			out = append(out, item["icon"])
+		}
+		return out
+	}
+
+	// The section's icon wins over its index's.
+	if got := icons(p.Menu); reflect.DeepEqual(got, []interface{}{"book", "compass", nil}) == false {
+		t.Fatalf("Expecting the icons of the sections on the menu, got %v", got)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "guide", "usage.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := icons(p.SideMenu); reflect.DeepEqual(got, []interface{}{"download", nil}) == false {
+		t.Fatalf("Expecting the icons of the pages on the side menu, got %v", got)
+	}
+}
+
+func TestIndexTitles(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":                   "# Home",
+		"getting-started/index.md":   "---\ntitle: Quick Start\n---\n# Welcome",
+		"getting-started/install.md": "# Install",
+		"api-notes/page.md":          "# Page",
+	})
+
+	p := b.NewPage(b.Root + PS + "getting-started/install.md")
+	p.CreateMenu()
+	p.CreateBreadCrumb()
+
+	texts := map[string]interface{}{}
+	for _, item := range p.Menu {
+		texts[item["link"].(string)] = item["text"]
+	}
+
+	expected := map[string]interface{}{
+		"/api-notes/":       "Api notes",
+		"/getting-started/": "Quick Start",
+	}
+
+	root := b.NewPage(b.Root + PS + "index.md")
+	root.CreateMenu()
+
+	for _, item := range root.Menu {
+		texts[item["link"].(string)] = item["text"]
+	}
+
+	if reflect.DeepEqual(texts, expected) == false {
+		t.Fatalf("Expecting menu texts %v, got %v", expected, texts)
+	}
+
+	if crumb := p.BreadCrumb[len(p.BreadCrumb)-1]; crumb["text"] != "Quick Start" {
+		t.Fatalf("Expecting the index title on the breadcrumb, got %v", crumb)
+	}
+
+	p = b.NewPage(b.Root + PS + "api-notes/page.md")
+	p.CreateBreadCrumb()
+
+	if crumb := p.BreadCrumb[len(p.BreadCrumb)-1]; crumb["text"] != "Api notes" {
+		t.Fatalf("Expecting a title from the directory name, got %v", crumb)
+	}
+}
+
+func TestSortItems(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"blog/_section.yaml": "sort: date\norder: desc\n",
+		"blog/a-first.md":    "---\ndate: 2013-01-05\n---\n# First",
+		"blog/b-third.md":    "---\ndate: 2013-03-01\n---\n# Third",
+		"blog/c-second.md":   "---\ndate: 2013-02-10\n---\n# Second",
+		"blog/d-undated.md":  "# Undated",
+		"docs/b.md":          "---\ndate: 2013-03-01\n---\n# B",
+		"docs/a.md":          "---\ndate: 2013-01-05\n---\n# A",
+		"docs/c.md":          "# C",
+	})
+
+	links := func(items []map[string]interface{}) []interface{} {
+		out := []interface{}{}
+		for _, item := range items {
+			out = append(out, item["link"])
+		}
+		return out
+	}
+
+	p := b.NewPage(b.Root + PS + "blog/a-first.md")
+	p.CreateSideMenu()
+
+	expected := []interface{}{"/blog/b-third", "/blog/c-second", "/blog/a-first", "/blog/d-undated"}
+
+	if reflect.DeepEqual(links(p.SideMenu), expected) == false {
+		t.Fatalf("Expecting newest first, got %v", links(p.SideMenu))
+	}
+
+	listing, err := b.BuildListing("blog", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reflect.DeepEqual(links(listing), expected) == false {
+		t.Fatalf("Expecting newest first on the listing, got %v", links(listing))
+	}
+
+	p = b.NewPage(b.Root + PS + "docs/a.md")
+	p.CreateSideMenu()
+
+	if l := links(p.SideMenu); reflect.DeepEqual(l, []interface{}{"/docs/a", "/docs/b", "/docs/c"}) == false {
+		t.Fatalf("Expecting name order, got %v", l)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Tells whether the robots directives in the given front matter keep the page
+// out of search engines (i.e: "noindex, nofollow" or "none").
+func isNoIndex(meta map[string]interface{}) bool {
+	for _, directive := range strings.Split(metaString(meta, "robots"), ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive == "noindex" || directive == "none" {
+			return true
+		}
+	}
+	return false
+}
+
+// Tells whether baseURL, if any, is on another scheme or host than siteURL.
+func isForeign(baseURL string, siteURL string) bool {
+	if baseURL == "" {
+		return false
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return true
+	}
+	site, err := url.Parse(siteURL)
+	if err != nil {
+		return true
+	}
+	return strings.EqualFold(base.Scheme, site.Scheme) == false || strings.EqualFold(base.Host, site.Host) == false
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// Returns a sitemap (see sitemaps.org) of the pages under root (a directory
+// within the content root), siteURL is the scheme and host the site is served
+// at (i.e: "http://example.org"). Unpublished pages, pages whose robots
+// directives include noindex and pages canonically hosted on another site
+// (see pageURL) are left out, sitemaps can only list URLs of their own host.
+func (b *Builder) BuildSitemap(root string, siteURL string) ([]byte, error) {
+	seen := map[string]sitemapURL{}
+
+	err := b.walkPublished(root, func(file string, info os.FileInfo, meta map[string]interface{}, url string) error {
+		if isNoIndex(meta) || isForeign(metaString(meta, "baseurl"), siteURL) {
+			return nil
+		}
+		seen[url] = sitemapURL{
+			Loc:     b.pageURL(meta, siteURL, strings.TrimPrefix(url, b.mountPath())),
+			LastMod: info.ModTime().UTC().Format("2006-01-02"),
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Error trying to build sitemap of %s: %s", root, err.Error())
+	}
+
+	set := sitemapURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+
+	for _, entry := range seen {
+		set.URLs = append(set.URLs, entry)
+	}
+
+	sort.Slice(set.URLs, func(i, j int) bool {
+		return set.URLs[i].Loc < set.URLs[j].Loc
+	})
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), out...), nil
+}
@@ -0,0 +1,75 @@
+package page
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRobots(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":   "# Home",
+		"hidden.md":  "---\nrobots: noindex,nofollow\n---\n# Hidden",
+		"none.md":    "---\nrobots: none\n---\n# None",
+		"follow.md":  "---\nrobots: nofollow\n---\n# Follow",
+		"guide/a.md": "# A",
+	})
+
+	p, err := b.Build(filepath.Join(b.Root, "hidden.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Robots != "noindex,nofollow" {
+		t.Fatalf("Expecting robots from front matter, got %q", p.Robots)
+	}
+
+	p, err = b.Build(filepath.Join(b.Root, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Robots != "" {
+		t.Fatalf("Expecting no robots directives, got %q", p.Robots)
+	}
+
+	sitemap, err := b.BuildSitemap(b.Root, "http://example.org/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(sitemap)
+
+	for _, loc := range []string{"http://example.org/", "http://example.org/follow", "http://example.org/guide/a"} {
+		if strings.Contains(out, "<loc>"+loc+"</loc>") == false {
+			t.Fatalf("Expecting %s on the sitemap, got %s", loc, out)
+		}
+	}
+
+	for _, loc := range []string{"http://example.org/hidden", "http://example.org/none"} {
+		if strings.Contains(out, "<loc>"+loc+"</loc>") == true {
+			t.Fatalf("Expecting %s to be left out of the sitemap, got %s", loc, out)
+		}
+	}
+}
+
+func TestSitemapForeignPages(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md": "# Home",
+		"moved.md": "---\nbaseurl: https://other.example.com/docs/\n---\n# Moved",
+		"local.md": "---\nbaseurl: http://EXAMPLE.org/v2/\n---\n# Local",
+	})
+
+	sitemap, err := b.BuildSitemap(b.Root, "http://example.org/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(sitemap)
+
+	if strings.Contains(out, "other.example.com") || strings.Contains(out, "/moved") {
+		t.Fatalf("Expecting pages hosted elsewhere to be left out, got %s", out)
+	}
+
+	if strings.Contains(out, "<loc>http://example.org/</loc>") == false || strings.Contains(out, "<loc>http://EXAMPLE.org/v2/local</loc>") == false {
+		t.Fatalf("Expecting the pages of this host, got %s", out)
+	}
+}
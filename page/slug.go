@@ -0,0 +1,216 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values of SlugCollisions.
+const (
+	// The first page, in file name order, gets the slug, the others are
+	// served under their file names (the default).
+	SLUG_COLLISION_FIRST = "first"
+	// The first page gets the slug, the others get it with a numeric suffix
+	// (i.e: "install-2").
+	SLUG_COLLISION_SUFFIX = "suffix"
+	// The first page gets the slug, the others are not served.
+	SLUG_COLLISION_ERROR = "error"
+)
+
+// Returns the slug on a page's front matter (i.e: "install" for a page that
+// is served at /install, whatever its file is named), if any.
+func slugOf(meta map[string]interface{}) string {
+	return strings.Trim(metaString(meta, "slug"), "/")
+}
+
+// The names the pages of a directory are served at (see slugTable).
+type slugTable struct {
+	// The SlugCollisions the table was made with.
+	policy string
+	served map[string]string
+	errs   map[string]error
+	// File names, by the slug they're served at.
+	bySlug map[string]string
+	// When a page of the directory is published or expires, and the table
+	// has to be made again (never if zero).
+	expires time.Time
+}
+
+// Returns the names the pages of directory with a slug are served at, by file
+// name, and why those that can't be served can't. Pages without a slug keep
+// their names, slugs that collide with them or with each other are resolved
+// according to SlugCollisions. Tables are kept until the cache is
+// invalidated or a page of the directory is published or expires, collisions
+// are logged when a table is made.
+func (b *Builder) slugTable(directory string) (map[string]string, map[string]error) {
+	table := b.cachedSlugTable(directory)
+	return table.served, table.errs
+}
+
+// Returns the slug table of directory (see slugTable), from the cache if it's
+// there.
+func (b *Builder) cachedSlugTable(directory string) *slugTable {
+	directory = strings.TrimRight(directory, PS)
+
+	// Not kept for directories that don't exist, any URL could name one.
+	if _, err := os.Stat(b.source(directory)); err != nil {
+		return &slugTable{}
+	}
+
+	now := b.now()
+
+	b.mu.Lock()
+	table, ok := b.slugCache[directory]
+	b.mu.Unlock()
+
+	if ok && table.expires.IsZero() == false && now.Before(table.expires) == false {
+		ok = false
+	}
+
+	if ok == false || table.policy != b.SlugCollisions {
+		table = &slugTable{policy: b.SlugCollisions, expires: b.upcoming(directory)}
+		table.served, table.errs = b.makeSlugTable(directory)
+		table.bySlug = map[string]string{}
+		for name, slug := range table.served {
+			table.bySlug[slug] = name
+		}
+		for _, err := range table.errs {
+			Logger.Printf("%s\n", err.Error())
+		}
+		b.mu.Lock()
+		if b.slugCache == nil {
+			b.slugCache = make(map[string]*slugTable)
+		}
+		b.slugCache[directory] = table
+		b.mu.Unlock()
+	}
+
+	return table
+}
+
+func (b *Builder) makeSlugTable(directory string) (map[string]string, map[string]error) {
+	served := map[string]string{}
+	errs := map[string]error{}
+
+	claimed := map[string]string{}
+	slugs := map[string]string{}
+	names := []string{}
+
+	for _, file := range b.filterList(directory, b.pageFilter) {
+		name := file.Name()
+		if removeKnownExtension(name) == "index" {
+			continue
+		}
+		meta, err := b.readMeta(b.source(directory + PS + name))
+		if err != nil || b.isPublished(meta) == false {
+			continue
+		}
+		if slug := slugOf(meta); slug != "" {
+			slugs[name] = slug
+			names = append(names, name)
+		} else {
+			claimed[removeKnownExtension(name)] = name
+		}
+	}
+
+	for _, name := range names {
+		slug := slugs[name]
+
+		if owner, taken := claimed[slug]; taken {
+			switch b.SlugCollisions {
+			case SLUG_COLLISION_ERROR:
+				errs[name] = fmt.Errorf("Could not serve %s at %s: %s is served there already.", name, slug, owner)
+				continue
+			case SLUG_COLLISION_SUFFIX:
+				n := 2
+				for {
+					if _, taken := claimed[slug+"-"+strconv.Itoa(n)]; taken == false {
+						break
+					}
+					n++
+				}
+				slug = slug + "-" + strconv.Itoa(n)
+			default:
+				slug = removeKnownExtension(name)
+			}
+		}
+
+		claimed[slug] = name
+		served[name] = slug
+	}
+
+	return served, errs
+}
+
+// Returns the name the page named name, within directory, is served at: its
+// slug, if any (see slugTable), or its file name without extension.
+func (b *Builder) servedName(directory string, name string, meta map[string]interface{}) (string, error) {
+	if slugOf(meta) == "" || removeKnownExtension(name) == "index" {
+		return b.pageName(name), nil
+	}
+
+	served, errs := b.slugTable(directory)
+
+	if err, ok := errs[name]; ok {
+		return "", err
+	}
+
+	if slug, ok := served[name]; ok {
+		return slug, nil
+	}
+
+	return b.pageName(name), nil
+}
+
+// Returns the page within directory served at the given slug, if any.
+func (b *Builder) findSlug(directory string, slug string) (string, bool) {
+	if name, ok := b.cachedSlugTable(directory).bySlug[slug]; ok {
+		return directory + PS + name, true
+	}
+
+	return "", false
+}
+
+// Links a menu or listing item, within the directory whose URL is prefix, to
+// the name its page is served at. Returns false if the page can't be served.
+func (b *Builder) applySlug(item map[string]interface{}, directory string, name string, meta map[string]interface{}, prefix string) bool {
+	if slugOf(meta) == "" {
+		return true
+	}
+
+	served, err := b.servedName(directory, name, meta)
+
+	if err != nil {
+		return false
+	}
+
+	item["link"] = b.linkFor(served, false, prefix)
+
+	return true
+}
@@ -0,0 +1,136 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Problems found on a page in StrictMode.
+type Problems []Problem
+
+func (problems Problems) Error() string {
+	lines := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		lines = append(lines, problem.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns the problems of a built page: links to pages or files that don't
+// exist, front matter problems (see ValidateFrontMatter), headings problems
+// (see LintHeadings), a slug other page got first and not being reachable
+// from the menus (see FindOrphans).
+func (p *Page) problems() Problems {
+	b := p.builder
+
+	rel := b.relPath(p.FilePath)
+
+	problems := Problems{}
+
+	for _, link := range linkAttrPattern.FindAllStringSubmatch(string(p.Content), -1) {
+		target := link[2][1 : len(link[2])-1]
+		if b.isDeadLink(target) {
+			problems = append(problems, Problem{File: rel, Message: "dead link to " + target})
+		}
+	}
+
+	problems = append(problems, b.metaProblems(rel, p.fullMeta(), b.RequiredFrontMatter)...)
+
+	for _, problem := range LintHeadings(p.Content) {
+		problem.File = rel
+		problems = append(problems, problem)
+	}
+
+	if slug := slugOf(p.fullMeta()); slug != "" && p.isIndex() == false {
+		served, err := b.servedName(p.FileDir, path.Base(p.FilePath), p.fullMeta())
+		if err == nil && served != slug {
+			problems = append(problems, Problem{File: rel, Key: "slug", Message: slug + " is taken by another page"})
+		}
+	}
+
+	if b.isOrphan(p.FilePath) {
+		problems = append(problems, Problem{File: rel, Message: "not reachable from the menus"})
+	}
+
+	return problems
+}
+
+// Reports the headings of rendered content that skip levels (i.e: an h4 right
+// after an h2) and every h1 after the first one. Problems have no File.
+func LintHeadings(content template.HTML) []Problem {
+	problems := []Problem{}
+
+	level, h1s := 0, 0
+
+	for _, heading := range headingPattern.FindAllStringSubmatch(string(content), -1) {
+		current, _ := strconv.Atoi(heading[1])
+		text := strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(heading[2], "")))
+
+		if current == 1 {
+			if h1s++; h1s > 1 {
+				problems = append(problems, Problem{Message: fmt.Sprintf("more than one h1: %q", text)})
+			}
+		}
+
+		if level > 0 && current > level+1 {
+			problems = append(problems, Problem{Message: fmt.Sprintf("heading skips from h%d to h%d: %q", level, current, text)})
+		}
+
+		level = current
+	}
+
+	return problems
+}
+
+// Tells whether a link on a page (made absolute by absoluteLinks) leads to
+// nothing on the site. External and fragment-only links, and links outside of
+// the site's mount path, are not checked.
+func (b *Builder) isDeadLink(link string) bool {
+	if i := strings.IndexAny(link, "?#"); i >= 0 {
+		link = link[:i]
+	}
+
+	mount := b.mountPath()
+
+	if link == "" || strings.HasPrefix(link, "/") == false || strings.HasPrefix(link+"/", mount) == false {
+		return false
+	}
+
+	file := b.Root + PS + strings.TrimLeft(strings.TrimPrefix(link, strings.TrimRight(mount, "/")), "/")
+
+	if _, transform := b.Resolve(file); transform != NO_TRANSFORM {
+		return false
+	}
+
+	stat, err := os.Stat(b.source(file))
+
+	return err != nil || stat.IsDir()
+}
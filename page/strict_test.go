@@ -0,0 +1,150 @@
+package page
+
+import (
+	"html/template"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStrictMode(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":          "# Home\n\nSee the [guide](guide/) and [intro](/guide/intro).",
+		"guide/index.md":    "# Guide\n\n![Logo](logo.png) [Home](/) [Top](#top) [Go](http://golang.org/)",
+		"guide/logo.png":    "PNG",
+		"guide/intro.md":    "# Intro\n\nGo on to [the next page](next).",
+		"_drafts/hidden.md": "# Hidden",
+	})
+
+	b.StrictMode = true
+
+	for _, file := range []string{"index.md", "guide/index.md"} {
+		if _, err := b.Build(b.Root + PS + file); err != nil {
+			t.Fatalf("Expecting %s to pass, got %s", file, err)
+		}
+	}
+
+	_, err := b.Build(b.Root + PS + "guide/intro.md")
+
+	problems, ok := err.(Problems)
+	if ok == false || len(problems) != 1 || strings.Contains(err.Error(), "dead link to /guide/next") == false {
+		t.Fatalf("Expecting a dead link, got %v", err)
+	}
+
+	if _, err := b.Build(b.Root + PS + "_drafts/hidden.md"); err == nil || strings.Contains(err.Error(), "not reachable") == false {
+		t.Fatalf("Expecting an orphan page, got %v", err)
+	}
+
+	b.RequiredFrontMatter = []string{"author"}
+
+	if _, err := b.Build(b.Root + PS + "index.md"); err == nil || strings.Contains(err.Error(), "author: missing required key") == false {
+		t.Fatalf("Expecting a missing key, got %v", err)
+	}
+
+	b.StrictMode = false
+
+	if _, err := b.Build(b.Root + PS + "guide/intro.md"); err != nil {
+		t.Fatalf("Expecting no checks without StrictMode, got %s", err)
+	}
+}
+
+func TestLintHeadings(t *testing.T) {
+	tests := []struct {
+		content  template.HTML
+		problems []string
+	}{
+		{
+			"<h1>Guide</h1><h2>Setup</h2><h4>Details</h4><h2>Usage</h2>",
+			[]string{`heading skips from h2 to h4: "Details"`},
+		},
+		{
+			"<h1>One</h1><p>Text.</p><h1>Two &amp; more</h1>",
+			[]string{`more than one h1: "Two & more"`},
+		},
+		{
+			"<h2>Intro</h2><h3>Why</h3><h4>Really</h4><h2 id=\"next\">Next</h2><h3>How</h3>",
+			[]string{},
+		},
+	}
+
+	for _, test := range tests {
+		messages := []string{}
+		for _, problem := range LintHeadings(test.content) {
+			messages = append(messages, problem.Message)
+		}
+		if reflect.DeepEqual(messages, test.problems) == false {
+			t.Fatalf("Expecting %v for %s, got %v", test.problems, test.content, messages)
+		}
+	}
+
+	b := testBuilder(t, map[string]string{
+		"index.md": "# Home\n\n### Too deep\n",
+	})
+
+	b.StrictMode = true
+
+	if _, err := b.Build(b.Root + PS + "index.md"); err == nil || strings.Contains(err.Error(), "index.md: heading skips from h1 to h3") == false {
+		t.Fatalf("Expecting strict mode to report the heading, got %v", err)
+	}
+}
+
+func TestStrictModeCombined(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":        "# Home",
+		"guide/index.md":  "# Guide",
+		"guide/intro.md":  "---\n_extends: doc\ntitle: Intro\n---\n# Intro :rocket:\n\n[[TOC]]\n\nThe lead.\n\n## Install :zap:\n\n    [[TOC]] :zap:\n",
+		"guide/setup.rst": "---\n_extends: doc\nrobots: noindex\n---\nSetup\n=====\n\nSome text.\n",
+		"guide/notes.txt": "Notes\n",
+	})
+
+	b.StrictMode = true
+	b.CachePages = true
+	b.EmojiReplace = true
+	b.TOCMarker = "[[TOC]]"
+	b.AllowedMetaKeys = []string{"title"}
+	b.Renderers = map[string]string{".txt": RENDERER_PRE}
+	b.FrontMatterProfiles = map[string]map[string]interface{}{
+		"doc": {"description": "Documentation", "og_type": "article"},
+	}
+
+	for _, file := range []string{"guide/intro.md", "guide/setup.rst", "guide/notes.txt"} {
+		if _, err := b.Build(b.Root + PS + file); err != nil {
+			t.Fatalf("%s: expecting no strict mode problems, got %v", file, err)
+		}
+	}
+
+	first, _ := b.Build(b.Root + PS + "guide/intro.md")
+	first.Meta["title"] = "Changed"
+
+	p, err := b.Build(b.Root + PS + "guide/intro.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reflect.DeepEqual(p.Meta, map[string]interface{}{"title": "Intro"}) == false {
+		t.Fatalf("Expecting only the allowed keys of a cached page, untouched, got %v", p.Meta)
+	}
+
+	if p.Description != "Documentation" || reflect.DeepEqual(p.FrontMatter, p.Meta) == false {
+		t.Fatalf("Expecting the profile to apply though its keys are filtered out, got %q and %v", p.Description, p.FrontMatter)
+	}
+
+	content := string(p.Content)
+
+	if strings.Count(content, `<ul class="toc"><li><a href="#intro">`) != 1 || strings.Contains(content, "<pre><code>[[TOC]] :zap:") == false {
+		t.Fatalf("Expecting the marker replaced outside code only, got %s", content)
+	}
+
+	if strings.Contains(content, "Install ⚡") == false || p.Lead != "The lead." {
+		t.Fatalf("Expecting emoji on headings and the lead after the marker, got %q and %s", p.Lead, content)
+	}
+
+	sitemap, err := b.BuildSitemap(b.Root, "http://example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(sitemap), "/guide/setup<") || strings.Contains(string(sitemap), "/guide/notes<") == false {
+		t.Fatalf("Expecting noindex pages left out and Renderer pages in, got %s", sitemap)
+	}
+}
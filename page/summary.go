@@ -0,0 +1,130 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// What listings need to know about a page, without its content.
+type Summary struct {
+	Title   string
+	Link    string
+	Date    time.Time
+	Excerpt string
+	Tags    []string
+	Meta    map[string]interface{}
+}
+
+// Returns a summary of the page at the given path, relative to the content
+// root (i.e: "guide/intro.md"). Only the beginning of the page is rendered:
+// its headings and first paragraph, the title comes from the former unless the
+// front matter has one, the excerpt from its description or the latter.
+func (b *Builder) PageSummary(contentRelPath string) (*Summary, error) {
+	rel := strings.Trim(path.Clean("/"+contentRelPath), "/")
+	file := b.Root + PS + rel
+
+	link, err := b.URLByPath(rel)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if stat, err := os.Stat(b.source(file)); err == nil && stat.IsDir() {
+		return nil, fmt.Errorf("Could not summarize %s: it's a directory.", contentRelPath)
+	}
+
+	meta, src, err := b.readSource(b.source(file))
+
+	if err != nil {
+		return nil, err
+	}
+
+	head := b.render(file, sourceHead(src, b.TOCMarker))
+
+	s := &Summary{
+		Title:   metaString(meta, "title"),
+		Link:    link,
+		Excerpt: metaString(meta, "description"),
+		Tags:    metaStrings(meta, "tags"),
+		Meta:    b.allowedMeta(meta),
+	}
+
+	if date, ok := parseDate(meta["date"]); ok {
+		s.Date = date
+	}
+
+	if s.Title == "" {
+		s.Title = b.extractTitle(string(head))
+	}
+
+	if s.Title == "" {
+		s.Title = b.fileTitle(rel)
+	}
+
+	if s.Excerpt == "" {
+		lead := b.leadOf(string(head))
+		s.Excerpt = strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(lead, "")))
+	}
+
+	return s, nil
+}
+
+// Returns the beginning of a page's source, up to its first block (lines
+// between blank lines) that is neither a heading nor the given marker.
+func sourceHead(src []byte, marker string) []byte {
+	offset := 0
+
+	for offset < len(src) {
+		end := bytes.Index(src[offset:], []byte("\n\n"))
+		if end < 0 {
+			return src
+		}
+		block := strings.TrimSpace(string(src[offset : offset+end]))
+		offset += end + 2
+		if block != "" && block != marker && isHeadingBlock(block) == false {
+			break
+		}
+	}
+
+	return src[:offset]
+}
+
+// Tells whether a block of source is a heading: an ATX (#) heading, an HTML
+// one or a title followed by an underline.
+func isHeadingBlock(block string) bool {
+	if strings.HasPrefix(block, "#") || headingPattern.MatchString(block) && strings.HasPrefix(block, "<h") {
+		return true
+	}
+
+	lines := strings.Split(block, "\n")
+
+	return len(lines) == 2 && isRSTUnderline(lines[1], lines[0])
+}
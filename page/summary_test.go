@@ -0,0 +1,59 @@
+package page
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPageSummary(t *testing.T) {
+	body := strings.Repeat("More *text* that takes a while to render.\n\n", 20000)
+
+	b := testBuilder(t, map[string]string{
+		"news/launch.md": "---\ndate: 2013-03-09\ntags: [release, news]\n---\n# We &amp; launched\n\n## Today\n\nThe *first* paragraph.\nStill first.\n\n" + body,
+		"news/blurb.md":  "---\ntitle: Blurb\ndescription: Set by hand.\n---\nJust text.\n",
+	})
+
+	s, err := b.PageSummary("news/launch.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Title != "We & launched" || s.Link != "/news/launch" || s.Date.Format("2006-01-02") != "2013-03-09" {
+		t.Fatalf("Unexpected summary %+v", s)
+	}
+	if s.Excerpt != "The first paragraph.\nStill first." || reflect.DeepEqual(s.Tags, []string{"release", "news"}) == false {
+		t.Fatalf("Unexpected excerpt or tags %q %v", s.Excerpt, s.Tags)
+	}
+
+	s, err = b.PageSummary("news/blurb.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Title != "Blurb" || s.Excerpt != "Set by hand." || s.Date.IsZero() == false || len(s.Tags) != 0 {
+		t.Fatalf("Expecting the front matter to win, got %+v", s)
+	}
+
+	if _, err := b.PageSummary("news/missing.md"); err == nil {
+		t.Fatalf("Expecting an error for missing pages")
+	}
+
+	allocated := func(fn func()) uint64 {
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		fn()
+		runtime.ReadMemStats(&after)
+		return after.TotalAlloc - before.TotalAlloc
+	}
+
+	summary := allocated(func() { b.PageSummary("news/launch.md") })
+	built := allocated(func() { b.Build(b.Root + PS + "news/launch.md") })
+
+	// Reading the source is all the summary has in common with building.
+	if summary*2 > built {
+		t.Fatalf("Expecting the summary to be cheaper than building the page, %d bytes allocated against %d", summary, built)
+	}
+}
@@ -0,0 +1,27 @@
+package page
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagCounts(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"a.md":        "---\ntags: [Go, web]\n---\n# A",
+		"b.md":        "---\ntags: [go, go, Templates]\n---\n# B",
+		"c/d.md":      "---\ntags: Web\n---\n# D",
+		"untagged.md": "# Untagged",
+		"draft.md":    "---\ndraft: true\ntags: [go]\n---\n# Draft",
+	})
+
+	counts, err := b.TagCounts(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{"go": 2, "web": 2, "templates": 1}
+
+	if reflect.DeepEqual(counts, expected) == false {
+		t.Fatalf("Expecting %v, got %v", expected, counts)
+	}
+}
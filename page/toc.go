@@ -0,0 +1,173 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	anchoredHeadingPattern = regexp.MustCompile(`<h([1-6])[^>]*\sid="([^"]+)"[^>]*>(.+?)</h[1-6]>`)
+	plainHeadingPattern    = regexp.MustCompile(`<h([1-6])>(.+?)</h[1-6]>`)
+	anchorPattern          = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+type tocEntry struct {
+	level int
+	item  map[string]interface{}
+}
+
+// Returns the table of contents of the given HTML: an item for each heading
+// with an id, linking to it, with those of the headings below it as its
+// "children".
+func BuildTOC(content template.HTML) []map[string]interface{} {
+	entries := []tocEntry{}
+
+	for _, found := range anchoredHeadingPattern.FindAllStringSubmatch(string(content), -1) {
+		level, _ := strconv.Atoi(found[1])
+		entries = append(entries, tocEntry{level, map[string]interface{}{
+			"link": "#" + html.UnescapeString(found[2]),
+			"text": strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(found[3], ""))),
+		}})
+	}
+
+	return nestTOC(entries)
+}
+
+func nestTOC(entries []tocEntry) []map[string]interface{} {
+	items := []map[string]interface{}{}
+
+	for i := 0; i < len(entries); {
+		j := i + 1
+		for j < len(entries) && entries[j].level > entries[i].level {
+			j++
+		}
+		item := entries[i].item
+		if j > i+1 {
+			item["children"] = nestTOC(entries[i+1 : j])
+		}
+		items = append(items, item)
+		i = j
+	}
+
+	return items
+}
+
+// Returns the items of a table of contents as nested lists.
+func renderTOC(items []map[string]interface{}) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	out := `<ul class="toc">`
+
+	for _, item := range items {
+		out += fmt.Sprintf(`<li><a href="%s">%s</a>`, template.HTMLEscapeString(item["link"].(string)), template.HTMLEscapeString(item["text"].(string)))
+		if children, ok := item["children"].([]map[string]interface{}); ok {
+			out += renderTOC(children)
+		}
+		out += "</li>"
+	}
+
+	return out + "</ul>"
+}
+
+// Gives every heading without an id one made from its text (i.e: "getting
+// started" for "Getting Started"), so the table of contents can link to it.
+func anchorHeadings(content string) string {
+	taken := map[string]bool{}
+
+	for _, found := range anchoredHeadingPattern.FindAllStringSubmatch(content, -1) {
+		taken[found[2]] = true
+	}
+
+	return plainHeadingPattern.ReplaceAllStringFunc(content, func(heading string) string {
+		found := plainHeadingPattern.FindStringSubmatch(heading)
+
+		text := html.UnescapeString(tagPattern.ReplaceAllString(found[2], ""))
+		base := strings.Trim(anchorPattern.ReplaceAllString(strings.ToLower(text), "-"), "-")
+		if base == "" {
+			base = "section"
+		}
+
+		id := base
+		for i := 2; taken[id]; i++ {
+			id = base + "-" + strconv.Itoa(i)
+		}
+		taken[id] = true
+
+		return `<h` + found[1] + ` id="` + id + `">` + found[2] + `</h` + found[1] + `>`
+	})
+}
+
+// Left by markTOC where the TOCMarker was, until postRender replaces it.
+const tocPlaceholder = "<!-- luminos:toc -->"
+
+// Replaces the TOCMarker, wherever it's not code, with tocPlaceholder, so it
+// is never taken for the lead of the content.
+func (b *Builder) markTOC(content string) string {
+	if b == nil || b.TOCMarker == "" || strings.Contains(content, b.TOCMarker) == false {
+		return content
+	}
+
+	content = replaceText(content, func(text string) string {
+		return strings.Replace(text, b.TOCMarker, tocPlaceholder, -1)
+	})
+
+	// Markdown leaves a marker on a line of its own in a paragraph.
+	return strings.Replace(content, "<p>"+tocPlaceholder+"</p>", tocPlaceholder, -1)
+}
+
+// Returns the lead of rendered content (see extractLead), the TOCMarker is
+// never taken for it.
+func (b *Builder) leadOf(content string) string {
+	lead, _ := extractLead(b.markTOC(content))
+	return strings.Replace(lead, tocPlaceholder, "", -1)
+}
+
+// Post-processes the page's rendered content: the TOCMarker, if any, is
+// replaced with the page's table of contents, or removed with DisableTOC.
+// The marker is expected to be already replaced by markTOC.
+func (p *Page) postRender() {
+	p.Lead = template.HTML(strings.Replace(string(p.Lead), tocPlaceholder, "", -1))
+
+	if strings.Contains(string(p.Content), tocPlaceholder) == false {
+		return
+	}
+
+	content := string(p.Content)
+	toc := ""
+
+	if p.builder.DisableTOC == false {
+		content = anchorHeadings(content)
+		toc = renderTOC(BuildTOC(template.HTML(content)))
+	}
+
+	p.Content = template.HTML(strings.Replace(content, tocPlaceholder, toc, -1))
+}
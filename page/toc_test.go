@@ -0,0 +1,90 @@
+package page
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTOCMarker(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide.md": "# Guide\n\n[[TOC]]\n\n## Install\n\n### On Linux\n\n### On Mac\n\n## Usage\n",
+		"plain.md": "# Plain\n\n## Install\n",
+	})
+
+	b.TOCMarker = "[[TOC]]"
+
+	p, err := b.Build(b.Root + PS + "guide.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<ul class="toc"><li><a href="#guide">Guide</a><ul class="toc">` +
+		`<li><a href="#install">Install</a><ul class="toc">` +
+		`<li><a href="#on-linux">On Linux</a></li><li><a href="#on-mac">On Mac</a></li></ul></li>` +
+		`<li><a href="#usage">Usage</a></li></ul></li></ul>`
+
+	if strings.Contains(string(p.Content), expected) == false || strings.Contains(string(p.Content), "[[TOC]]") {
+		t.Fatalf("Expecting the marker to be replaced with %s, got %s", expected, p.Content)
+	}
+
+	if strings.Contains(string(p.Content), `<h3 id="on-linux">On Linux</h3>`) == false {
+		t.Fatalf("Expecting headings to be linkable, got %s", p.Content)
+	}
+
+	p, err = b.Build(b.Root + PS + "plain.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(p.Content) != "<h1>Plain</h1>\n\n<h2>Install</h2>\n" {
+		t.Fatalf("Expecting pages without the marker to be left alone, got %q", p.Content)
+	}
+
+	b.DisableTOC = true
+
+	p, err = b.Build(b.Root + PS + "guide.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(p.Content), "[[TOC]]") || strings.Contains(string(p.Content), "toc") {
+		t.Fatalf("Expecting the marker to be removed, got %s", p.Content)
+	}
+}
+
+func TestTOCMarkerCodeAndLead(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide.md": "# Guide\n\n[[TOC]]\n\nThe real lead.\n\n## Install\n\n    Write [[TOC]] where it goes.\n\nOr `[[TOC]]` inline.\n",
+	})
+
+	b.TOCMarker = "[[TOC]]"
+	b.RemoveLead = true
+
+	p, err := b.Build(b.Root + PS + "guide.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Lead != "The real lead." {
+		t.Fatalf("Expecting the marker not to be taken for the lead, got %q", p.Lead)
+	}
+
+	content := string(p.Content)
+
+	if strings.Count(content, `<ul class="toc">`) != 2 || strings.Contains(content, "The real lead.") {
+		t.Fatalf("Expecting the marker to be replaced and the lead removed, got %s", content)
+	}
+
+	if strings.Contains(content, "<pre><code>Write [[TOC]] where it goes.") == false || strings.Contains(content, "<code>[[TOC]]</code>") == false {
+		t.Fatalf("Expecting markers within code to be left as they are, got %s", content)
+	}
+
+	s, err := b.PageSummary("guide.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Excerpt != "The real lead." {
+		t.Fatalf("Expecting the marker not to be taken for the excerpt, got %q", s.Excerpt)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Returns the path of file relative to the content root, with forward slashes.
+func (b *Builder) relPath(file string) string {
+	rel, err := filepath.Rel(b.Root, file)
+	if err != nil {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Calls fn with the front matter and URL of every published page under root
+// (a directory within the content root), in lexical order. Hidden files are
+// skipped. Files are read on up to Concurrency goroutines, fn is called on
+// the calling one.
+func (b *Builder) walkPublished(root string, fn func(file string, info os.FileInfo, meta map[string]interface{}, url string) error) error {
+	type entry struct {
+		file string
+		info os.FileInfo
+		meta map[string]interface{}
+		url  string
+	}
+
+	entries := []*entry{}
+
+	err := b.walkPages(root, func(file string, info os.FileInfo) error {
+		entries = append(entries, &entry{file: file, info: info})
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	err = b.forEach(len(entries), func(i int) error {
+		e := entries[i]
+
+		meta, err := b.readMeta(b.source(e.file))
+
+		if err != nil {
+			return err
+		}
+
+		if b.isPublished(meta) == false {
+			return nil
+		}
+
+		e.url, err = b.URLByPath(b.relPath(e.file))
+		e.meta = meta
+
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.url == "" {
+			continue
+		}
+		if err := fn(e.file, e.info, e.meta, e.url); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns every URL the pages under root (a directory within the content
+// root) are served at, sorted. Hidden files and unpublished pages are left
+// out.
+func (b *Builder) AllURLs(root string) ([]string, error) {
+	seen := map[string]bool{}
+
+	err := b.walkPublished(root, func(file string, info os.FileInfo, meta map[string]interface{}, url string) error {
+		seen[url] = true
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []string{}
+	for url, _ := range seen {
+		urls = append(urls, url)
+	}
+
+	sort.Strings(urls)
+
+	return urls, nil
+}
+
+// Returns the URL a request for requestPath on requestHost should be
+// redirected to, if requestHost is not the CanonicalHost. Ports are only
+// compared if CanonicalHost has one. URLs are http:// unless CanonicalHost
+// begins with another scheme (i.e: "https://example.org").
+func (b *Builder) CanonicalRedirect(requestHost string, requestPath string) (string, bool) {
+	if b == nil || b.CanonicalHost == "" {
+		return "", false
+	}
+
+	scheme, canonical := "http://", b.CanonicalHost
+
+	if i := strings.Index(canonical, "://"); i >= 0 {
+		scheme, canonical = canonical[:i+3], canonical[i+3:]
+	}
+
+	canonical = strings.TrimRight(canonical, "/")
+
+	host := requestHost
+
+	if _, _, err := net.SplitHostPort(canonical); err != nil {
+		// No port to compare.
+		if name, _, err := net.SplitHostPort(requestHost); err == nil {
+			host = name
+		}
+	}
+
+	if strings.EqualFold(host, canonical) {
+		return "", false
+	}
+
+	return scheme + canonical + "/" + strings.TrimLeft(requestPath, "/"), true
+}
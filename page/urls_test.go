@@ -0,0 +1,137 @@
+package page
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestAllURLs(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":          "# Home",
+		"about.md":          "# About",
+		"guide/index.md":    "# Guide",
+		"guide/index.html":  "<h1>Guide</h1>",
+		"guide/intro.md":    "# Intro",
+		"guide/table.html":  "<h1>Table</h1>",
+		"guide/draft.md":    "---\ndraft: true\n---\n# Draft\n",
+		"guide/_header.md":  "Header",
+		"guide/notes.txt":   "Not a page.",
+		"_private/page.md":  "# Hidden",
+		"empty/.keep":       "",
+		"posts/2013/one.md": "# One",
+	})
+
+	urls, err := b.AllURLs(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"/",
+		"/about",
+		"/guide/",
+		"/guide/intro",
+		"/guide/table",
+		"/posts/2013/one",
+	}
+
+	if reflect.DeepEqual(urls, expected) == false {
+		t.Fatalf("Expecting %v, got %v", expected, urls)
+	}
+
+	b.Prefix = "docs"
+
+	urls, err = b.AllURLs(b.Root + PS + "guide")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []string{"/docs/guide/", "/docs/guide/intro", "/docs/guide/table"}
+
+	if reflect.DeepEqual(urls, expected) == false {
+		t.Fatalf("Expecting %v, got %v", expected, urls)
+	}
+}
+
+func TestConcurrentBulkBuild(t *testing.T) {
+	files := map[string]string{}
+	for i := 0; i < 60; i++ {
+		files[fmt.Sprintf("section-%d/page-%02d.md", i%4, i)] = fmt.Sprintf("---\ndraft: %v\n---\n# Page %d", i%7 == 0, i)
+	}
+
+	b := testBuilder(t, files)
+
+	b.Concurrency = 1
+
+	expectedURLs, err := b.AllURLs(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSitemap, err := b.BuildSitemap(b.Root, "http://example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSections, err := b.BuildGroupedIndex(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(expectedURLs) != 51 {
+		t.Fatalf("Expecting 51 published pages, got %d", len(expectedURLs))
+	}
+
+	b.Concurrency = 8
+
+	for i := 0; i < 5; i++ {
+		urls, err := b.AllURLs(b.Root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sitemap, err := b.BuildSitemap(b.Root, "http://example.org")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sections, err := b.BuildGroupedIndex(b.Root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reflect.DeepEqual(urls, expectedURLs) == false ||
+			bytes.Equal(sitemap, expectedSitemap) == false ||
+			reflect.DeepEqual(sections, expectedSections) == false {
+			t.Fatalf("Expecting the same results whatever the concurrency.")
+		}
+	}
+}
+
+func TestCanonicalRedirect(t *testing.T) {
+	b := testBuilder(t, map[string]string{})
+
+	if _, ok := b.CanonicalRedirect("www.example.org", "/guide/"); ok {
+		t.Fatalf("Expecting no redirection without a CanonicalHost.")
+	}
+
+	b.CanonicalHost = "example.org"
+
+	if target, ok := b.CanonicalRedirect("www.example.org", "/guide/?q=1"); ok == false || target != "http://example.org/guide/?q=1" {
+		t.Fatalf("Expecting www to redirect to the apex, got %q (%v)", target, ok)
+	}
+
+	for _, host := range []string{"example.org", "EXAMPLE.org", "example.org:8080"} {
+		if target, ok := b.CanonicalRedirect(host, "/guide/"); ok {
+			t.Fatalf("Expecting %s to need no redirection, got %q", host, target)
+		}
+	}
+
+	b.CanonicalHost = "https://example.org"
+
+	if target, ok := b.CanonicalRedirect("www.example.org", "/"); ok == false || target != "https://example.org/" {
+		t.Fatalf("Expecting a redirection to https, got %q (%v)", target, ok)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"time"
+)
+
+// Who may see a page, see Page.Visibility.
+type Visibility int
+
+const (
+	// Anyone.
+	VISIBILITY_PUBLIC Visibility = iota
+	// Nobody, it's a draft.
+	VISIBILITY_DRAFT
+	// Nobody yet, it's dated in the future.
+	VISIBILITY_SCHEDULED
+	// Nobody anymore, it's past its "expires" date.
+	VISIBILITY_EXPIRED
+	// Only readers with the role of an _access.yaml file (see AccessPolicy).
+	VISIBILITY_RESTRICTED
+)
+
+func (v Visibility) String() string {
+	switch v {
+	case VISIBILITY_DRAFT:
+		return "draft"
+	case VISIBILITY_SCHEDULED:
+		return "scheduled"
+	case VISIBILITY_EXPIRED:
+		return "expired"
+	case VISIBILITY_RESTRICTED:
+		return "restricted"
+	}
+	return "public"
+}
+
+// Returns who may see the page now, according to the builder's clock. Drafts,
+// scheduled and expired pages are so even with ShowDrafts set, pages left out of
+// menus are still public.
+func (p *Page) Visibility() Visibility {
+	if p.builder == nil {
+		return p.visibilityAt(time.Now())
+	}
+	return p.visibilityAt(p.builder.now())
+}
+
+func (p *Page) visibilityAt(now time.Time) Visibility {
+	meta := p.fullMeta()
+
+	if isDraft(meta) {
+		return VISIBILITY_DRAFT
+	}
+
+	if date, ok := parseDate(meta["date"]); ok && date.After(now) {
+		return VISIBILITY_SCHEDULED
+	}
+
+	if isExpired(meta, now) {
+		return VISIBILITY_EXPIRED
+	}
+
+	if p.builder != nil {
+		if _, restricted := p.builder.AccessPolicy(p.BasePath); restricted {
+			return VISIBILITY_RESTRICTED
+		}
+	}
+
+	return VISIBILITY_PUBLIC
+}
+
+// Tells whether anyone may see the page at the time given by clock (the
+// builder's clock if nil).
+func (p *Page) IsPublic(clock func() time.Time) bool {
+	if clock == nil {
+		return p.Visibility() == VISIBILITY_PUBLIC
+	}
+	return p.visibilityAt(clock()) == VISIBILITY_PUBLIC
+}
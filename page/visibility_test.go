@@ -0,0 +1,52 @@
+package page
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVisibility(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"public.md":            "---\ndate: 2013-04-01\n---\n# Public",
+		"draft.md":             "---\ndraft: true\n---\n# Draft",
+		"scheduled.md":         "---\ndate: 2013-06-01\n---\n# Scheduled",
+		"expired.md":           "---\nexpires: 2013-04-01\n---\n# Expired",
+		"staff/_access.yaml":   "role: staff\n",
+		"staff/handbook.md":    "# Handbook",
+		"staff/drafts/plan.md": "---\ndraft: true\n---\n# Plan",
+	})
+
+	b.Now = func() time.Time { return time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC) }
+	b.ShowDrafts = true
+
+	tests := map[string]Visibility{
+		"public.md":            VISIBILITY_PUBLIC,
+		"draft.md":             VISIBILITY_DRAFT,
+		"scheduled.md":         VISIBILITY_SCHEDULED,
+		"expired.md":           VISIBILITY_EXPIRED,
+		"staff/handbook.md":    VISIBILITY_RESTRICTED,
+		"staff/drafts/plan.md": VISIBILITY_DRAFT,
+	}
+
+	for file, expected := range tests {
+		p, err := b.Build(b.Root + PS + file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v := p.Visibility(); v != expected {
+			t.Fatalf("Expecting %s to be %s, got %s", file, expected, v)
+		}
+		if p.IsPublic(nil) != (expected == VISIBILITY_PUBLIC) {
+			t.Fatalf("Expecting %s not to be public", file)
+		}
+	}
+
+	p, err := b.Build(b.Root + PS + "scheduled.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.IsPublic(func() time.Time { return time.Date(2013, 7, 1, 0, 0, 0, 0, time.UTC) }) == false {
+		t.Fatalf("Expecting the scheduled page to be public once its date is past.")
+	}
+}
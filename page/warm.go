@@ -0,0 +1,171 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// How many cache lookups were answered from a cache (hits) and how many had
+// to go to disk (misses), since the builder was created.
+type CacheStats struct {
+	MenuHits   int
+	MenuMisses int
+	PageHits   int
+	PageMisses int
+}
+
+// Returns the builder's cache counters.
+func (b *Builder) CacheStats() CacheStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// A cached page, and when it stops being valid (never if zero).
+type pageEntry struct {
+	page    *Page
+	expires time.Time
+}
+
+// Returns a copy of the page cached for file, if any.
+func (b *Builder) cachedPage(file string) (*Page, bool) {
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	entry, ok := b.pageCache[file]
+	if ok && entry.expires.IsZero() == false && now.Before(entry.expires) == false {
+		delete(b.pageCache, file)
+		ok = false
+	}
+	if ok == false {
+		b.stats.PageMisses++
+		return nil, false
+	}
+	b.stats.PageHits++
+	return entry.page.copy(), true
+}
+
+// Caches a copy of the page built for file. Pages with a "date" or an
+// "expires" date still to come are only kept until then, as their
+// publishing changes at that time.
+func (b *Builder) cachePage(file string, p *Page) {
+	now := b.now()
+	meta := p.fullMeta()
+
+	if isExpired(meta, now) {
+		return
+	}
+
+	entry := &pageEntry{page: p.copy(), expires: nextChange(meta, now)}
+
+	b.mu.Lock()
+	b.pageCache[file] = entry
+	b.mu.Unlock()
+}
+
+// Returns a copy of the page that shares none of its menus, front matter or
+// lists with it, so cached pages can't be modified through the ones handed
+// out. Data is shared by every page.
+func (p *Page) copy() *Page {
+	c := *p
+	c.Menu = copyMenu(p.Menu)
+	c.TopMenu = copyMenu(p.TopMenu)
+	c.SideMenu = copyMenu(p.SideMenu)
+	c.BreadCrumb = copyMenu(p.BreadCrumb)
+	c.HomeSections = copyMenu(p.HomeSections)
+	c.CurrentPage = copyMeta(p.CurrentPage)
+	c.Parent = copyMeta(p.Parent)
+	c.Prev = copyMeta(p.Prev)
+	c.Next = copyMeta(p.Next)
+	c.Section = copyMeta(p.Section)
+	c.Meta = copyMeta(p.Meta)
+	c.meta = copyMeta(p.meta)
+	c.FrontMatter = copyMeta(p.FrontMatter)
+	if p.Styles != nil {
+		c.Styles = append([]string(nil), p.Styles...)
+	}
+	if p.Scripts != nil {
+		c.Scripts = append([]string(nil), p.Scripts...)
+	}
+	return &c
+}
+
+// Builds and caches the menu of every directory under root (a directory
+// within the content root) so the first requests don't have to walk the
+// tree. With CachePages set every published page is rendered and cached too,
+// except for those larger than MaxContentBytes. Directories and pages are
+// built on up to Concurrency goroutines.
+func (b *Builder) WarmCache(root string) error {
+	dirs := []string{}
+
+	err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() == false {
+			return nil
+		}
+		if file != root && isHidden(info.Name()) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, file)
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	err = b.forEach(len(dirs), func(i int) error {
+		b.NewPage(dirs[i] + PS + "index").CreateMenu()
+		return nil
+	})
+
+	if err != nil || b.CachePages == false {
+		return err
+	}
+
+	files := []string{}
+
+	err = b.walkPublished(root, func(file string, info os.FileInfo, meta map[string]interface{}, url string) error {
+		if err := b.checkSize(file); err != nil {
+			Logger.Printf("Not caching %s: %s\n", file, err.Error())
+			return nil
+		}
+		files = append(files, file)
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return b.forEach(len(files), func(i int) error {
+		_, err := b.Build(files[i])
+		return err
+	})
+}
@@ -0,0 +1,129 @@
+package page
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWarmCache(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":            "# Home",
+		"guide/index.md":      "# Guide",
+		"guide/intro.md":      "# Intro",
+		"guide/basics/one.md": "# One",
+		"api/large.md":        "# Large\n\nThis one has a lot more to say than the limit allows.",
+		"_drafts/a.md":        "# A",
+	})
+
+	b.CachePages = true
+	b.MaxContentBytes = 32
+
+	if err := b.WarmCache(b.Root); err != nil {
+		t.Fatal(err)
+	}
+
+	reads := 0
+
+	defer func(original func(string) []os.DirEntry) { readEntries = original }(readEntries)
+
+	original := readEntries
+	readEntries = func(directory string) []os.DirEntry {
+		reads++
+		return original(directory)
+	}
+
+	before := b.CacheStats()
+
+	for _, file := range []string{"index.md", "guide/intro.md", "guide/basics/one.md"} {
+		b.NewPage(b.Root + PS + file).CreateMenu()
+	}
+
+	after := b.CacheStats()
+
+	// One for each menu, plus one for the root's menu that each nested page
+	// has as its TopMenu.
+	if after.MenuHits-before.MenuHits != 5 || after.MenuMisses != before.MenuMisses {
+		t.Fatalf("Expecting every menu to come from the cache, got %+v then %+v", before, after)
+	}
+
+	p, err := b.Build(b.Root + PS + "guide/intro.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reads != 0 {
+		t.Fatalf("Expecting no directory to be read, got %d reads", reads)
+	}
+
+	if p.Title != "Intro" || b.CacheStats().PageHits != 1 {
+		t.Fatalf("Expecting the page to come from the cache, got %q and %+v", p.Title, b.CacheStats())
+	}
+
+	if _, cached := b.pageCache[b.Root+PS+"api/large.md"]; cached || len(b.pageCache) != 4 {
+		t.Fatalf("Expecting every page but the large one to be cached, got %d pages", len(b.pageCache))
+	}
+}
+
+func TestCachedPagesCopied(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"guide/index.md": "# Guide",
+		"guide/intro.md": "---\nauthor:\n  name: Ana\ntags: [basics]\n---\n# Intro",
+		"guide/setup.md": "# Setup",
+	})
+
+	b.CachePages = true
+
+	p, err := b.Build(b.Root + PS + "guide/intro.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.Meta["author"].(map[interface{}]interface{})["name"] = "Bo"
+	p.Meta["tags"].([]interface{})[0] = "advanced"
+	p.SideMenu[0]["text"] = "Changed"
+	p.Menu = nil
+
+	p, err = b.Build(b.Root + PS + "guide/intro.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b.CacheStats().PageHits != 1 {
+		t.Fatalf("Expecting the page to come from the cache, got %+v", b.CacheStats())
+	}
+
+	if p.Meta["author"].(map[interface{}]interface{})["name"] != "Ana" || p.Meta["tags"].([]interface{})[0] != "basics" || p.SideMenu[0]["text"] == "Changed" {
+		t.Fatalf("Expecting the cached page to be left alone, got %v and %v", p.Meta, p.SideMenu)
+	}
+}
+
+func TestCachedPagesExpire(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"news.md": "---\nexpires: 2030-01-02\n---\n# News",
+	})
+
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.Now = func() time.Time { return now }
+	b.CachePages = true
+
+	for i := 0; i < 2; i++ {
+		if _, err := b.Build(b.Root + PS + "news.md"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if stats := b.CacheStats(); stats.PageHits != 1 {
+		t.Fatalf("Expecting the page to be cached until it expires, got %+v", stats)
+	}
+
+	now = now.AddDate(0, 0, 2)
+
+	if _, err := b.Build(b.Root + PS + "news.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, cached := b.pageCache[b.Root+PS+"news.md"]; cached || b.CacheStats().PageHits != 1 {
+		t.Fatalf("Expecting the expired page not to be served from the cache, got %+v", b.CacheStats())
+	}
+}
@@ -0,0 +1,131 @@
+/*
+  Copyright (c) 2012-2013 José Carlos Nieto, http://xiam.menteslibres.org/
+
+  Permission is hereby granted, free of charge, to any person obtaining
+  a copy of this software and associated documentation files (the
+  "Software"), to deal in the Software without restriction, including
+  without limitation the rights to use, copy, modify, merge, publish,
+  distribute, sublicense, and/or sell copies of the Software, and to
+  permit persons to whom the Software is furnished to do so, subject to
+  the following conditions:
+
+  The above copyright notice and this permission notice shall be
+  included in all copies or substantial portions of the Software.
+
+  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND,
+  EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF
+  MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND
+  NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE
+  LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION
+  OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION
+  WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+*/
+
+package page
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// How often Watch looks for changes.
+var watchInterval = time.Millisecond * 500
+
+/*
+	Like the watcher package, this is a (stupid) polling watcher, it walks the
+	whole tree on every tick and compares modification times.
+*/
+type snapshot map[string]time.Time
+
+// Directories are recorded without a modification time: they change with
+// every file added or removed within them, which is reported already, only
+// adding or removing them is.
+func takeSnapshot(root string) snapshot {
+	snap := snapshot{}
+	filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil || file == root {
+			return nil
+		}
+		if info.IsDir() {
+			snap[file] = time.Time{}
+		} else {
+			snap[file] = info.ModTime()
+		}
+		return nil
+	})
+	return snap
+}
+
+// Watches the content files under root, the path of every file that is added,
+// modified or removed is sent to the returned channel and the cached menus
+// depending on it are invalidated. The returned function stops watching and
+// closes the channel.
+func (b *Builder) Watch(root string) (<-chan string, func(), error) {
+	stat, err := os.Stat(root)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error trying to watch %s: %s", root, err.Error())
+	}
+
+	if stat.IsDir() == false {
+		return nil, nil, fmt.Errorf("Could not watch %s: not a directory.", root)
+	}
+
+	changes := make(chan string)
+	done := make(chan bool)
+
+	last := takeSnapshot(root)
+
+	go func() {
+		defer close(changes)
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(watchInterval):
+			}
+
+			current := takeSnapshot(root)
+
+			changed := []string{}
+
+			for file, mtime := range current {
+				if prev, ok := last[file]; ok == false || prev != mtime {
+					changed = append(changed, file)
+				}
+			}
+
+			for file, _ := range last {
+				if _, ok := current[file]; ok == false {
+					changed = append(changed, file)
+				}
+			}
+
+			last = current
+
+			if len(changed) > 0 {
+				b.InvalidateFiles(changed)
+			}
+
+			for _, file := range changed {
+				select {
+				case changes <- file:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	stop := func() {
+		once.Do(func() { close(done) })
+	}
+
+	return changes, stop, nil
+}
@@ -0,0 +1,78 @@
+package page
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	b := testBuilder(t, map[string]string{
+		"index.md":       "# Home",
+		"guide/intro.md": "# Intro",
+	})
+
+	p := b.NewPage(filepath.Join(b.Root, "index.md"))
+	p.CreateMenu()
+
+	if _, ok := b.cachedMenu(p.FileDir + p.BasePath); ok == false {
+		t.Fatalf("Expecting the menu to be cached.")
+	}
+
+	changes, stop, err := b.Watch(b.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	file := filepath.Join(b.Root, "guide", "new.md")
+	if err := ioutil.WriteFile(file, []byte("# New"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case changed := <-changes:
+		if changed != file {
+			t.Fatalf("Expecting %s, got %s", file, changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s.", file)
+	}
+
+	if _, ok := b.cachedMenu(p.FileDir + p.BasePath); ok == true {
+		t.Fatalf("Expecting the menu cache to be invalidated.")
+	}
+
+	// Empty directories are noticed too, when added and when removed.
+	dir := filepath.Join(b.Root, "tools")
+
+	for _, change := range []func(string) error{func(dir string) error { return os.Mkdir(dir, 0755) }, os.Remove} {
+		p = b.NewPage(filepath.Join(b.Root, "index.md"))
+		p.CreateMenu()
+
+		if err := change(dir); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case changed := <-changes:
+			if changed != dir {
+				t.Fatalf("Expecting %s, got %s", dir, changed)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %s.", dir)
+		}
+
+		if _, ok := b.cachedMenu(p.FileDir + p.BasePath); ok == true {
+			t.Fatalf("Expecting the menu cache to be invalidated by %s.", dir)
+		}
+	}
+
+	stop()
+
+	if _, ok := <-changes; ok == true {
+		t.Fatalf("Expecting the channel to be closed after stop.")
+	}
+}